@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/mensaah/reka/plan"
+	"github.com/mensaah/reka/provider/types"
+	"github.com/mensaah/reka/rules"
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply <planfile>",
+	Short: "Apply a plan created with `reka plan --out`",
+	Long: `Executes exactly the changes in a plan file. Resources are refreshed from the providers
+before acting and any resource whose status or tags changed since the plan was created
+is skipped. Resources not in the plan are never touched.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		p, err := plan.Read(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if p.Empty() {
+			fmt.Println("Plan has no changes. Nothing to apply")
+			return
+		}
+
+		initReka()
+		ctx, cancel := newRunContext()
+		defer cancel()
+		providers = initProviders(ctx)
+		for _, name := range p.Providers() {
+			if getProvider(name) == nil {
+				log.Fatalf("Provider %s in plan is not enabled in config", name)
+			}
+		}
+
+		refreshResources(ctx, providers)
+		if ctx.Err() != nil {
+			return
+		}
+		drifted, failed := 0, 0
+		for _, provider := range providers {
+			accepted, errs := p.Verify(provider.Name, activeState.Current[provider.Name])
+			for _, err := range errs {
+				log.Errorf("Skipping %s", err)
+			}
+			drifted += len(errs)
+
+			if res, ok := accepted[rules.Stop]; ok {
+				log.Infof("Stopping %d %s resources", res.Count(), provider.Name)
+				failed += logResults(rules.Stop, provider.StopResources(ctx, res))
+			}
+			if res, ok := accepted[rules.Resume]; ok {
+				log.Infof("Resuming %d %s resources", res.Count(), provider.Name)
+				failed += logResults(rules.Resume, provider.ResumeResources(ctx, res))
+			}
+			if res, ok := accepted[rules.Destroy]; ok {
+				log.Infof("Destroying %d %s resources", res.Count(), provider.Name)
+				failed += logResults(rules.Destroy, provider.DestroyResources(ctx, res))
+			}
+		}
+		writeState()
+		if drifted > 0 {
+			log.Warnf("%d planned changes were skipped because resources changed since the plan was created", drifted)
+		}
+		if failed > 0 {
+			cancel()
+			log.Fatalf("Actions failed on %d resources", failed)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+}
+
+func getProvider(name string) *types.Provider {
+	for _, p := range providers {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
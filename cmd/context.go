@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// withRunTimeout returns a context which is cancelled once the configured runTimeout passes
+func withRunTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if cfg.RunTimeout > 0 {
+		return context.WithTimeout(ctx, cfg.RunTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// cancelOnSignal returns a context which is cancelled on SIGINT or SIGTERM, so calls in progress stop and the
+// run ends. A second signal exits immediately
+func cancelOnSignal(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case s := <-sig:
+			log.Warnf("Received %s, cancelling calls in progress. Send again to exit immediately", s)
+			signal.Stop(sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sig)
+		cancel()
+	}
+}
+
+// newRunContext returns the context of a single run of reka. It is cancelled on SIGINT or SIGTERM or once the
+// configured runTimeout passes
+func newRunContext() (context.Context, context.CancelFunc) {
+	ctx, cancelSignal := cancelOnSignal(context.Background())
+	ctx, cancel := withRunTimeout(ctx)
+	return ctx, func() {
+		cancel()
+		cancelSignal()
+	}
+}
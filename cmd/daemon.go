@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var daemonTick time.Duration
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:     "daemon",
+	Aliases: []string{"serve"},
+	Short:   "Run reka continuously, refreshing and acting on resources every refreshInterval",
+	Long: `Runs the refresh, evaluate and act cycle once on start and then every refreshInterval hours.
+Use --tick to also run the cycle at a finer interval so that schedule based rules such as
+activeDuration are acted on close to their start and stop times.
+
+Only one cycle runs at a time and each cycle is limited by runTimeout. On SIGINT or SIGTERM,
+calls in progress are cancelled and the daemon waits for a running cycle to end before exiting.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		initReka()
+		if cfg.RefreshInterval <= 0 {
+			log.Fatalf("refreshInterval must be greater than 0, got %d", cfg.RefreshInterval)
+		}
+		if daemonTick != 0 && daemonTick < time.Second {
+			log.Fatalf("tick must be at least 1s, got %s", daemonTick)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		initCtx, cancelInit := withRunTimeout(ctx)
+		providers = initProviders(initCtx)
+		cancelInit()
+		runner := &cycleRunner{ctx: ctx}
+		scheduler := gocron.NewScheduler(time.UTC)
+		// The refresh job also runs the first cycle when the scheduler starts
+		if _, err := scheduler.Every(uint64(cfg.RefreshInterval)).Hours().Do(runner.refresh); err != nil {
+			log.Fatalf("Could not schedule refresh: %s", err)
+		}
+		scheduler.StartAsync()
+		interval := time.Duration(cfg.RefreshInterval) * time.Hour
+		if daemonTick > 0 {
+			// Jobs added once the scheduler is running first run after their interval
+			seconds := uint64(daemonTick / time.Second)
+			if _, err := scheduler.Every(seconds).Seconds().Do(runner.run, false); err != nil {
+				log.Fatalf("Could not schedule tick: %s", err)
+			}
+			log.Infof("Running every %s with a tick of %s", interval, daemonTick)
+		} else {
+			log.Infof("Running every %s", interval)
+		}
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		s := <-sig
+		log.Infof("Received %s, cancelling running cycle", s)
+		cancel()
+		scheduler.Stop()
+		runner.stop()
+		log.Info("Reka stopped")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().DurationVar(&daemonTick, "tick", 0, "Also run the cycle at this interval e.g 5m (disabled by default)")
+}
+
+// cycleRunner runs reconcile ensuring only one cycle runs at a time. Cycles triggered while
+// another is in progress are skipped
+type cycleRunner struct {
+	// ctx is cancelled when the daemon stops
+	ctx     context.Context
+	running int32
+	// refreshes counts the runs of the refresh job
+	refreshes int32
+	// mu guards stopped so no cycle is added to wg once stop waits on it
+	mu      sync.Mutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// refresh runs a cycle every refreshInterval. Accounts are discovered again from the second refresh on, as they
+// were discovered when the providers were initialized
+func (c *cycleRunner) refresh() {
+	c.run(atomic.AddInt32(&c.refreshes, 1) > 1)
+}
+
+// run runs a cycle. Providers discover their accounts again first if discover is set
+func (c *cycleRunner) run(discover bool) {
+	c.mu.Lock()
+	if c.stopped {
+		c.mu.Unlock()
+		return
+	}
+	c.wg.Add(1)
+	c.mu.Unlock()
+	defer c.wg.Done()
+	if c.ctx.Err() != nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&c.running, 0, 1) {
+		log.Info("Previous cycle still running, skipping")
+		return
+	}
+	defer atomic.StoreInt32(&c.running, 0)
+
+	ctx, cancel := withRunTimeout(c.ctx)
+	defer cancel()
+	start := time.Now()
+	log.Info("Starting cycle")
+	if discover {
+		discoverAccounts(ctx)
+	}
+	reconcile(ctx)
+	log.Infof("Cycle completed in %s", time.Since(start).Round(time.Second))
+}
+
+// discoverAccounts discovers the accounts of providers again. Providers keep their accounts when discovery fails
+func discoverAccounts(ctx context.Context) {
+	for _, p := range providers {
+		if p.Discover == nil {
+			continue
+		}
+		if err := p.Discover(ctx); err != nil {
+			log.Errorf("Could not discover accounts of provider %s, keeping the accounts found before: %s", p.Name, err)
+		}
+	}
+}
+
+// stop prevents new cycles from starting and blocks until a running cycle completes
+func (c *cycleRunner) stop() {
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+	c.wg.Wait()
+}
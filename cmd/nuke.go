@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/plan"
+	"github.com/mensaah/reka/provider/types"
+	"github.com/mensaah/reka/resource"
+	"github.com/mensaah/reka/rules"
+)
+
+var (
+	nukeResources []string
+	nukeRegions   []string
+	nukeConfirm   []string
+)
+
+// nukeCmd represents the nuke command
+var nukeCmd = &cobra.Command{
+	Use:   "nuke",
+	Short: "Destroy every resource reka can find in the configured providers",
+	Long: `Destroys all resources reka can discover for the providers in config, regardless of rules.
+Resources matching exclude rules are never destroyed. Use --resources and --regions to limit
+the resources destroyed.
+
+Before anything is destroyed, the account (AWS) or project (GCP) ID of each provider must be
+typed to confirm. Providers managing several accounts or projects are confirmed with their IDs
+separated by commas e.g 111111111111,222222222222. For unattended runs, pass the IDs with --confirm.`,
+	Example: `  reka nuke --config config.yaml
+  reka nuke --config config.yaml --resources aws.ec2,aws.ebs --regions us-east-1
+  reka nuke --config config.yaml --confirm 123456789012`,
+	Run: func(cmd *cobra.Command, args []string) {
+		initReka()
+
+		ctx, cancel := newRunContext()
+		defer cancel()
+		providers = initProviders(ctx)
+		reader := bufio.NewReader(os.Stdin)
+		targets := make(map[string]types.Resources)
+		for _, p := range providers {
+			id, err := p.AccountID(ctx)
+			if err != nil {
+				log.Fatalf("Could not get account of provider %s: %s", p.Name, err)
+			}
+			targets[p.Name] = filterNukeResources(p.Name, p.GetAllResources(ctx))
+
+			preview := plan.New()
+			for mgrName, resList := range targets[p.Name] {
+				for _, r := range resList {
+					if !rules.IsExcluded(r) {
+						preview.Add(p.Name, rules.Destroy, types.Resources{mgrName: {r}})
+					}
+				}
+			}
+			preview.Print(os.Stdout)
+			if !confirmNuke(reader, p.Name, id) {
+				log.Fatalf("Confirmation for provider %s does not match %s. Aborting", p.Name, id)
+			}
+		}
+
+		reports := make(map[string]types.NukeReport)
+		for _, p := range providers {
+			reports[p.Name] = p.Nuke(ctx, targets[p.Name])
+		}
+		printNukeReport(reports)
+		refreshResources(ctx, providers)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nukeCmd)
+	nukeCmd.Flags().StringSliceVar(&nukeResources, "resources", []string{}, "Only destroy these resources e.g aws.ec2,gcp.gke (default all)")
+	nukeCmd.Flags().StringSliceVar(&nukeRegions, "regions", []string{}, "Only destroy resources in these regions or zones, wildcards e.g eu-* are allowed (default all)")
+	nukeCmd.Flags().StringSliceVar(&nukeConfirm, "confirm", []string{}, "Account or project IDs to confirm without prompting")
+}
+
+// confirmNuke asks the operator to type the account IDs of a provider unless they were all passed with --confirm.
+// IDs are compared as a set, so several IDs can be given in any order
+func confirmNuke(reader *bufio.Reader, providerName, id string) bool {
+	ids := splitIDs(id)
+	if containsAll(nukeConfirm, ids) {
+		return true
+	}
+	fmt.Printf("All %s resources in %s will be DESTROYED. Type %s to confirm: ", providerName, id, id)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	typed := splitIDs(input)
+	return len(typed) == len(ids) && containsAll(typed, ids)
+}
+
+// splitIDs splits comma separated IDs, dropping empty ones
+func splitIDs(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// containsAll checks if every value of values is in list
+func containsAll(list, values []string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		if !config.Contains(list, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterNukeResources keeps the resources of providerName matching --resources and --regions. Resources are
+// matched with --resources by the manager they were listed by
+func filterNukeResources(providerName string, resources types.Resources) types.Resources {
+	filtered := make(types.Resources)
+	for mgrName, resList := range resources {
+		if len(nukeResources) > 0 && !config.Contains(nukeResources, providerName+"."+mgrName) {
+			continue
+		}
+		for _, r := range resList {
+			if !rules.InRegions(r, nukeRegions) {
+				continue
+			}
+			filtered[mgrName] = append(filtered[mgrName], r)
+		}
+	}
+	return filtered
+}
+
+func printNukeReport(reports map[string]types.NukeReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nPROVIDER\tRESOURCE\tID\tRESULT\tDURATION\tERROR")
+	destroyed, failed, skipped, excluded := 0, 0, 0, 0
+	for providerName, report := range reports {
+		for _, r := range report.Results {
+			outcome := string(r.Outcome)
+			switch r.Outcome {
+			case resource.Succeeded:
+				outcome = "destroyed"
+				destroyed++
+			case resource.Failed:
+				failed++
+			case resource.Skipped:
+				skipped++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", providerName, r.Resource.Manager.Name, r.Resource.Key(), outcome,
+				r.Duration.Round(time.Millisecond), r.Reason)
+		}
+		for mgrName, resList := range report.Excluded {
+			for _, r := range resList {
+				fmt.Fprintf(w, "%s\t%s\t%s\texcluded\t\t\n", providerName, mgrName, r.Key())
+				excluded++
+			}
+		}
+	}
+	w.Flush()
+	fmt.Printf("\nNuke complete: %d destroyed, %d failed, %d skipped, %d excluded\n", destroyed, failed, skipped, excluded)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/mensaah/reka/plan"
+	"github.com/mensaah/reka/rules"
+)
+
+var planOutFile string
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the actions reka would take without applying them",
+	Long: `Evaluates the rules against all resources and prints the resources that would be
+stopped, resumed or destroyed. No resource is modified and state is not written.
+
+The plan can be saved to a file with --out for review before it is applied.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		initReka()
+
+		ctx, cancel := newRunContext()
+		defer cancel()
+		providers = initProviders(ctx)
+		p := plan.New()
+		for _, provider := range providers {
+			res := provider.GetAllResources(ctx)
+			p.Add(provider.Name, rules.Stop, provider.GetStoppableResources(res))
+			p.Add(provider.Name, rules.Resume, provider.GetResumableResources(res))
+			p.Add(provider.Name, rules.Destroy, provider.GetDestroyableResources(res))
+		}
+		p.Print(os.Stdout)
+
+		if planOutFile != "" {
+			if err := p.Write(planOutFile); err != nil {
+				log.Fatal(err)
+			}
+			log.Infof("Plan saved to %s", planOutFile)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	planCmd.Flags().StringVarP(&planOutFile, "out", "o", "", "Write the plan to a file")
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"log"
 	"os"
 	"text/template"
@@ -57,8 +58,8 @@ to quickly create a Cobra application.`,
 			Providers []*types.Provider
 		}
 		p := pr{Providers: []*types.Provider{}}
-		awsProvider, _ := aws.NewProvider()
-		gcpProvider, _ := gcp.NewProvider()
+		awsProvider, _ := aws.NewProvider(context.Background())
+		gcpProvider, _ := gcp.NewProvider(context.Background())
 
 		p.Providers = append(p.Providers, awsProvider, gcpProvider)
 
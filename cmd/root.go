@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -55,45 +56,50 @@ var rootCmd = &cobra.Command{
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load Config and Defaults
-		config.LoadConfig()
-
-		cfg = config.GetConfig()
-		err := rules.LoadRules()
-		if err != nil {
-			log.Fatal(err)
+		initReka()
+		ctx, cancel := newRunContext()
+		defer cancel()
+		providers = initProviders(ctx)
+		if failed := reconcile(ctx); failed > 0 {
+			cancel()
+			log.Fatalf("Actions failed on %d resources", failed)
 		}
+	},
+}
 
-		// Initialize Provider objects
-		providers = initProviders()
-		backend = state.InitBackend()
-		// RefreshResources on every execution
-		refreshResources(providers)
-		for _, p := range providers {
-			res := activeState.Current[p.Name]
-
-			if !disableStop {
-				stoppableResources := p.GetStoppableResources(res)
-				fmt.Println("Stoppable Resources: ", stoppableResources)
-				errs := p.StopResources(stoppableResources)
-				logErrors(errs)
-			}
+// reconcile refreshes resources from all providers and stops, resumes or destroys resources
+// according to the rules. Calls to the providers stop when ctx is done. It returns the number of
+// resources an action failed on
+func reconcile(ctx context.Context) int {
+	// RefreshResources on every execution
+	refreshResources(ctx, providers)
+	if ctx.Err() != nil {
+		return 0
+	}
+	failed := 0
+	for _, p := range providers {
+		res := activeState.Current[p.Name]
 
-			if !disableResume {
-				resumableResources := p.GetResumableResources(res)
-				fmt.Println("Resumable Resources: ", resumableResources)
-				errs := p.ResumeResources(resumableResources)
-				logErrors(errs)
-			}
+		if !disableStop {
+			stoppableResources := p.GetStoppableResources(res)
+			fmt.Println("Stoppable Resources: ", stoppableResources)
+			failed += logResults(rules.Stop, p.StopResources(ctx, stoppableResources))
+		}
 
-			if !disableDestroy {
-				destroyableResources := p.GetDestroyableResources(res)
-				fmt.Println("Destroyable Resources: ", destroyableResources)
-				errs := p.DestroyResources(destroyableResources)
-				logErrors(errs)
-			}
+		if !disableResume {
+			resumableResources := p.GetResumableResources(res)
+			fmt.Println("Resumable Resources: ", resumableResources)
+			failed += logResults(rules.Resume, p.ResumeResources(ctx, resumableResources))
 		}
-	},
+
+		if !disableDestroy {
+			destroyableResources := p.GetDestroyableResources(res)
+			fmt.Println("Destroyable Resources: ", destroyableResources)
+			failed += logResults(rules.Destroy, p.DestroyResources(ctx, destroyableResources))
+		}
+	}
+	writeState()
+	return failed
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -110,9 +116,9 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.reka.yaml)")
 	rootCmd.Flags().BoolP("unused-only", "t", false, "Reap only unused resources")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Output verbose logs (DEBUG)")
-	rootCmd.Flags().BoolVar(&disableStop, "disable-stop", false, "Disable stopping of resources")
-	rootCmd.Flags().BoolVar(&disableResume, "disable-resume", false, "Disable resuming of resources")
-	rootCmd.Flags().BoolVar(&disableDestroy, "disable-destroy", false, "Disable destruction of resources")
+	rootCmd.PersistentFlags().BoolVar(&disableStop, "disable-stop", false, "Disable stopping of resources")
+	rootCmd.PersistentFlags().BoolVar(&disableResume, "disable-resume", false, "Disable resuming of resources")
+	rootCmd.PersistentFlags().BoolVar(&disableDestroy, "disable-destroy", false, "Disable destruction of resources")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -147,7 +153,24 @@ func initConfig() {
 
 }
 
-func initProviders() []*types.Provider {
+// initReka loads config and rules and initializes the state backend. Providers are initialized separately with
+// initProviders once the context of the run is created, as it depends on config
+func initReka() {
+	// Load Config and Defaults
+	config.LoadConfig()
+
+	cfg = config.GetConfig()
+	err := rules.LoadRules()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	backend = state.InitBackend()
+}
+
+// initProviders initializes the providers in config. Calls made to discover accounts, regions and projects stop
+// when ctx is done
+func initProviders(ctx context.Context) []*types.Provider {
 	var providers []*types.Provider
 	for _, p := range config.GetProviders() {
 		var (
@@ -156,9 +179,9 @@ func initProviders() []*types.Provider {
 		)
 		switch p {
 		case aws.GetName():
-			provider, err = aws.NewProvider()
+			provider, err = aws.NewProvider(ctx)
 		case gcp.GetName():
-			provider, err = gcp.NewProvider()
+			provider, err = gcp.NewProvider(ctx)
 		}
 		if err != nil {
 			log.Fatalf("Could not initialize %s Provider: %s", p, err.Error())
@@ -170,14 +193,31 @@ func initProviders() []*types.Provider {
 }
 
 // Refresh current status of resources from Providers
-func refreshResources(providers []*types.Provider) {
+func refreshResources(ctx context.Context, providers []*types.Provider) {
 	// activeState is the current state stored in backend
 	activeState = backend.GetState()
+	previous := activeState.Current
 
-	activeState.Current = make(state.ProvidersState)
+	current := make(state.ProvidersState)
 	for _, provider := range providers {
-		res := provider.GetAllResources()
-		activeState.Current[provider.Name] = res
+		res := provider.GetAllResources(ctx)
+		current[provider.Name] = res
+	}
+	// Resources may be missing when calls were cancelled, so state is left as it was
+	if err := ctx.Err(); err != nil {
+		log.Errorf("Refreshing resources stopped: %s. State is not updated", err)
+		return
+	}
+	activeState.Current = current
+	// Outcomes of earlier actions are kept until reka acts on the resource again
+	for providerName, resources := range current {
+		for mgrName, res := range resources {
+			for _, r := range res {
+				if p := resource.Find(previous[providerName][mgrName], r); p != nil {
+					r.LastAction = p.LastAction
+				}
+			}
+		}
 	}
 
 	// Add new resources to desired state if they don't already exists
@@ -187,7 +227,7 @@ func refreshResources(providers []*types.Provider) {
 			for u, w := range currentProviderResources {
 				if _, ok := activeState.Desired[currentProvider][u]; ok {
 					for _, res := range w {
-						if !containsResource(activeState.Desired[currentProvider][u], res) {
+						if resource.Find(activeState.Desired[currentProvider][u], res) == nil {
 							activeState.Desired[currentProvider][u] = append(activeState.Desired[currentProvider][u], res)
 						}
 					}
@@ -200,20 +240,31 @@ func refreshResources(providers []*types.Provider) {
 		}
 	}
 
-	backend.WriteState(activeState)
+	writeState()
 }
 
-func containsResource(res []*resource.Resource, r *resource.Resource) bool {
-	for _, rs := range res {
-		if rs.UUID == r.UUID {
-			return true
-		}
+func writeState() {
+	if err := backend.WriteState(activeState); err != nil {
+		log.Errorf("Could not write state: %s", err)
 	}
-	return false
 }
 
-func logErrors(errs map[string]error) {
-	for k, v := range errs {
-		log.Errorf("%s: %s", k, v.Error())
+// logResults logs the resources action failed on or skipped and a count of each outcome. The outcome is recorded on
+// each resource, which is saved with the state. It returns the number of resources action failed on
+func logResults(action rules.Action, results []*resource.Result) int {
+	counts := make(map[resource.Outcome]int)
+	for _, r := range results {
+		r.Record(action.String())
+		counts[r.Outcome]++
+		switch r.Outcome {
+		case resource.Failed:
+			log.Errorf("%s %s failed after %s: %s", r.Resource, r.Resource.Key(), r.Duration, r.Reason)
+		case resource.Skipped:
+			log.Warnf("%s %s skipped: %s", r.Resource, r.Resource.Key(), r.Reason)
+		}
+	}
+	if len(results) > 0 {
+		log.Infof("%d succeeded, %d failed, %d skipped", counts[resource.Succeeded], counts[resource.Failed], counts[resource.Skipped])
 	}
+	return counts[resource.Failed]
 }
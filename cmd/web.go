@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/mensaah/reka/web"
+)
+
+// webCmd represents the web command
+var webCmd = &cobra.Command{
+	Use:   "web",
+	Short: "Start the reka web dashboard",
+	Long: `Starts a web dashboard listing all resources tracked by reka with their status and the rule
+which applies to them. Resources can also be stopped, resumed or destroyed from the dashboard.
+
+The dashboard listens on web.address (default :8080) and requires web.auth.username and
+web.auth.password to be set in config.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		initReka()
+		ctx, cancel := withRunTimeout(context.Background())
+		providers = initProviders(ctx)
+		refreshResources(ctx, providers)
+		cancel()
+
+		server := web.NewServer(providers, backend, func(ctx context.Context) {
+			ctx, cancel := withRunTimeout(ctx)
+			defer cancel()
+			refreshResources(ctx, providers)
+		})
+		if err := server.Run(); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(webCmd)
+}
@@ -2,14 +2,80 @@ package config
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsCfg "github.com/aws/aws-sdk-go-v2/config"
 
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	log "github.com/sirupsen/logrus"
 )
 
+// AllRegions can be set in `aws.regions` to manage resources in all regions enabled for the account
+const AllRegions = "all"
+
+// AwsOptions are the settings under `aws` which are not part of the AWS SDK config
+type AwsOptions struct {
+	// Regions reka discovers and acts on resources in. Defaults to aws.defaultRegion
+	Regions []string
+	// Accounts reka manages resources in. Defaults to the account of the configured credentials
+	Accounts []AwsAccount
+	// Organization adds the member accounts of the AWS Organization of the configured credentials to Accounts
+	Organization *AwsOrganization
+}
+
+// AwsOrganization discovers the member accounts of an AWS Organization. The configured credentials must belong to
+// the management account or a delegated administrator of the organization
+type AwsOrganization struct {
+	// RoleName is the role assumed in each member account. Defaults to OrganizationAccountAccessRole
+	RoleName string
+	// ExternalID is passed when assuming RoleName if set
+	ExternalID string
+	// OrganizationalUnits limits accounts to those in these OUs or OUs within them e.g ou-ab12-cdef3456
+	OrganizationalUnits []string
+	// Tags limits accounts to those with all of these tags
+	Tags map[string]string
+	// Regions overrides aws.regions for the member accounts
+	Regions []string
+}
+
+// AwsAccount is an AWS account reka manages resources in
+type AwsAccount struct {
+	Name string
+	// Profile is a profile in the shared config and credentials files to use instead of the default credentials
+	Profile string
+	// RoleArn is a role to assume in the account
+	RoleArn string
+	// ExternalID is passed when assuming RoleArn if set
+	ExternalID string
+	// Regions overrides aws.regions for the account
+	Regions []string
+}
+
+// LoadAwsAccountConfig returns the config to access an account. base is used unless the account sets a profile
+func LoadAwsAccountConfig(base aws.Config, account AwsAccount) (aws.Config, error) {
+	cfg := base.Copy()
+	if account.Profile != "" {
+		var err error
+		cfg, err = awsCfg.LoadDefaultConfig(context.TODO(), awsCfg.WithSharedConfigProfile(account.Profile), awsCfg.WithRegion(base.Region))
+		if err != nil {
+			return cfg, fmt.Errorf("Could not load profile %s: %s", account.Profile, err)
+		}
+	}
+	if account.RoleArn != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), account.RoleArn, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = "reka"
+			if account.ExternalID != "" {
+				o.ExternalID = aws.String(account.ExternalID)
+			}
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+	return cfg, nil
+}
+
 func loadAwsConfig(accessKeyID, secretAccessKey, defaultRegion string) aws.Config {
 	var (
 		err error
@@ -1,10 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	log "github.com/sirupsen/logrus"
@@ -22,33 +24,118 @@ const (
 )
 
 type ExcludeRule struct {
-	Name      string
-	Region    string
-	Tags      map[string]string
-	Resources []string
+	Name   string `json:"name"`
+	Region string `json:"region,omitempty"`
+	// Regions lists regions, zones or locations. They can contain wildcards e.g eu-*
+	Regions   []string          `json:"regions,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Resources []string          `json:"resources,omitempty"`
+	// Accounts lists IDs of AWS accounts or GCP projects
+	Accounts []string `json:"accounts,omitempty"`
+}
+
+// GetRegions returns the regions the exclude rule is scoped to
+func (r ExcludeRule) GetRegions() []string {
+	return regions(r.Region, r.Regions)
+}
+
+// regions combines the region and regions of a rule
+func regions(region string, regions []string) []string {
+	if region == "" {
+		return regions
+	}
+	return append([]string{region}, regions...)
+}
+
+// ActiveWindow is a period of the day within which resources should be active on certain days of the week
+type ActiveWindow struct {
+	StartTime string `json:"startTime,omitempty"`
+	StopTime  string `json:"stopTime,omitempty"`
+	// StartDay and StopDay define an inclusive range of days e.g Monday to Friday
+	StartDay string `json:"startDay,omitempty"`
+	StopDay  string `json:"stopDay,omitempty"`
+	// Days lists days or ranges of days e.g [Monday, Wednesday-Friday]
+	Days []string `json:"days,omitempty"`
+}
+
+// ActiveDuration defines the weekly schedule within which resources should be active
+type ActiveDuration struct {
+	ActiveWindow `mapstructure:",squash"`
+	// Windows allows multiple active periods in a week e.g different hours on weekends
+	Windows []ActiveWindow `json:"windows,omitempty"`
+}
+
+// Schedule defines when resources are resumed and stopped with cron expressions e.g 0 7 * * 1-5
+type Schedule struct {
+	Resume string `json:"resume,omitempty"`
+	Stop   string `json:"stop,omitempty"`
+}
+
+// Calendar is a named set of days e.g public holidays. Days are listed in Dates or read from an iCalendar file
+type Calendar struct {
+	Name string `json:"name"`
+	// Dates are days in the form 2006-01-02 or inclusive ranges of days in the form 2006-01-02/2006-01-10
+	Dates []string `json:"dates,omitempty"`
+	// File is the path to an iCalendar (.ics) file
+	File string `json:"file,omitempty"`
 }
 
 type Rule struct {
-	Name      string
+	Name string `json:"name"`
+	// Priority orders the evaluation of rules. Rules with a higher priority are evaluated first
+	Priority  int `json:"priority,omitempty"`
 	Condition struct {
-		ActiveDuration struct {
-			StartTime string
-			StopTime  string
-			StartDay  string
-			StopDay   string
-		}
-		TerminationPolicy string
-		TerminationDate   string
-	}
-	Resources []string
-	Region    string
-	Tags      map[string]string
+		ActiveDuration    ActiveDuration `json:"activeDuration"`
+		Schedule          Schedule       `json:"schedule"`
+		TerminationPolicy string         `json:"terminationPolicy,omitempty"`
+		TerminationDate   string         `json:"terminationDate,omitempty"`
+		// MaxAge is how long after creation resources are destroyed e.g 24h, 7d
+		MaxAge string `json:"maxAge,omitempty"`
+	} `json:"condition"`
+	Resources []string          `json:"resources,omitempty"`
+	Region    string            `json:"region,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	// Regions lists regions, zones or locations. They can contain wildcards e.g eu-*
+	Regions []string `json:"regions,omitempty"`
+	// Accounts lists IDs of AWS accounts or GCP projects
+	Accounts []string `json:"accounts,omitempty"`
+	// Timezone overrides the global timezone used to evaluate time conditions of the rule
+	Timezone string `json:"timezone,omitempty"`
+	// Holidays are names of calendars whose days resources are kept off on
+	Holidays []string `json:"holidays,omitempty"`
 }
 
 func (r Rule) String() string {
 	return r.Name
 }
 
+// GetRegions returns the regions the rule is scoped to
+func (r Rule) GetRegions() []string {
+	return regions(r.Region, r.Regions)
+}
+
+// Precedence of rules in config and `reka-*` tags on resources when both target a resource
+const (
+	RulesPrecedence = "rules"
+	TagsPrecedence  = "tags"
+)
+
+// Conflict policies decide the action on a resource when rules resolve to different actions
+const (
+	// FirstMatch uses the action of the first rule in order of priority
+	FirstMatch = "first-match"
+	// MostDestructive uses the most destructive action. Destroy beats Stop, which beats Resume
+	MostDestructive = "most-destructive"
+)
+
+// Tracking modes decide which resources reka acts on
+const (
+	// IncludeAll tracks all resources except those tagged `reka-exclude`
+	IncludeAll = "include-all"
+	// ExcludeAll tracks only resources tagged `reka-include`
+	ExcludeAll = "exclude-all"
+)
+
 type Backend struct {
 	Type   string
 	Path   string
@@ -68,6 +155,8 @@ type Config struct {
 	Database *DatabaseConfig
 
 	Web struct {
+		// Address the web dashboard listens on e.g :8080
+		Address string
 		// Authentication Details to login to Reka
 		Auth struct {
 			Username string
@@ -75,11 +164,39 @@ type Config struct {
 		}
 	}
 
-	staticPath string // Path to Static File
+	staticPath string         // Path to Static File
+	location   *time.Location // Location loaded from Timezone
 
 	// Exclude block prevents certain resources from been tracked or affected by reka.
 	Exclude []*ExcludeRule
 
+	// Calendars are named sets of days which rules can refer to
+	Calendars []*Calendar
+	// Freeze are names of calendars during which no resources are destroyed
+	Freeze []string
+	// ConflictPolicy decides the action when rules resolve to different actions. One of first-match or most-destructive
+	ConflictPolicy string
+	// Tracking is include-all to track all resources or exclude-all to track only resources tagged `reka-include`
+	Tracking string
+	// Precedence decides whether rules in config or `reka-*` tags on resources decide the action on a resource
+	// when they resolve to conflicting actions. One of rules or tags
+	Precedence string
+	// PageSize is the number of results requested per page when listing resources. Each API's default is used when
+	// it is 0, and it is limited to the range each API allows
+	PageSize int32
+	// Concurrency is the maximum number of regions or projects resources are listed in or acted on at once. There is
+	// no limit when it is 0
+	Concurrency int
+	// OperationTimeout limits each call to a resource manager e.g listing or stopping EC2 instances. RunTimeout limits
+	// a whole run of reka. There is no limit when they are 0
+	OperationTimeout time.Duration
+	RunTimeout       time.Duration
+	// Wait polls resources after they are stopped, resumed or destroyed until they reach the expected status.
+	// Resources which do not within WaitTimeout are reported as failed. WaitInterval is the time between polls
+	Wait         bool
+	WaitTimeout  time.Duration
+	WaitInterval time.Duration
+
 	// StateBackend is how state is stored (read & write)
 	// State files contain details used for infrastructure resumption and history of
 	// infrastructural management
@@ -89,6 +206,8 @@ type Config struct {
 	Rules []*Rule
 	// AWS Config
 	Aws *aws.Config
+	// AwsOptions are reka specific settings for AWS
+	AwsOptions AwsOptions `mapstructure:"-"`
 	// Gcp configuration
 	Gcp *Gcp
 }
@@ -118,6 +237,12 @@ func LoadConfig() *Config {
 	viper.SetDefault("StateBackend.Path", path.Join(workingDir, "reka-state.json"))
 	// viper.SetDefault("DbType", "sqlite") // Default Database type is sqlite
 	viper.SetDefault("LogPath", path.Join(workingDir, "logs"))
+	viper.SetDefault("Web.Address", ":8080")
+	viper.SetDefault("Precedence", RulesPrecedence)
+	viper.SetDefault("Tracking", IncludeAll)
+	viper.SetDefault("ConflictPolicy", FirstMatch)
+	viper.SetDefault("WaitTimeout", 10*time.Minute)
+	viper.SetDefault("WaitInterval", 15*time.Second)
 	viper.SetDefault("RefreshInterval", 4)             // interval between running refresh and checking for resources to updates
 	viper.SetDefault("aws.DefaultRegion", "us-east-2") // Default AWS Region for users https://docs.aws.amazon.com/emr/latest/ManagementGuide/emr-plan-region.html
 
@@ -147,6 +272,35 @@ func LoadConfig() *Config {
 		config.staticPath = path.Join(workingDir, config.staticPath)
 	}
 
+	config.location, err = LoadLocation(config.Timezone)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !Contains([]string{RulesPrecedence, TagsPrecedence}, config.Precedence) {
+		log.Fatalf("Invalid precedence %s, must be one of %s or %s", config.Precedence, RulesPrecedence, TagsPrecedence)
+	}
+
+	if !Contains([]string{IncludeAll, ExcludeAll}, config.Tracking) {
+		log.Fatalf("Invalid tracking %s, must be one of %s or %s", config.Tracking, IncludeAll, ExcludeAll)
+	}
+
+	if config.PageSize < 0 || config.Concurrency < 0 {
+		log.Fatal("pageSize and concurrency must not be negative")
+	}
+
+	if config.OperationTimeout < 0 || config.RunTimeout < 0 {
+		log.Fatal("operationTimeout and runTimeout must not be negative")
+	}
+
+	if config.Wait && (config.WaitTimeout <= 0 || config.WaitInterval <= 0) {
+		log.Fatal("waitTimeout and waitInterval must be positive")
+	}
+
+	if !Contains([]string{FirstMatch, MostDestructive}, config.ConflictPolicy) {
+		log.Fatalf("Invalid conflictPolicy %s, must be one of %s or %s", config.ConflictPolicy, FirstMatch, MostDestructive)
+	}
+
 	if len(config.Providers) < 1 {
 		log.Fatal("No providers specified. Reka needs atleast one provider to monitor")
 	}
@@ -171,6 +325,12 @@ func LoadConfig() *Config {
 
 	awsConfig := loadAwsConfig(viper.GetString("aws.AccessKeyID"), viper.GetString("aws.SecretAccessKey"), viper.GetString("aws.DefaultRegion"))
 	config.Aws = &awsConfig
+	if err := viper.UnmarshalKey("aws", &config.AwsOptions); err != nil {
+		log.Fatal(err)
+	}
+	if len(config.AwsOptions.Regions) == 0 {
+		config.AwsOptions.Regions = []string{awsConfig.Region}
+	}
 
 	if !path.IsAbs(config.LogPath) {
 		config.LogPath = path.Join(workingDir, config.LogPath)
@@ -211,6 +371,28 @@ func GetAWS() *aws.Config {
 	return config.Aws
 }
 
+// GetLocation returns the location all time based rules are evaluated in. It is set with `timezone`
+// and defaults to the local timezone of the host
+func GetLocation() *time.Location {
+	if config.location == nil {
+		return time.Local
+	}
+	return config.location
+}
+
+// LoadLocation returns the location for a timezone name e.g Africa/Lagos. An empty name returns
+// the local timezone of the host
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid timezone %s: %s", name, err)
+	}
+	return loc, nil
+}
+
 //StaticPath returns path to application static folder
 func StaticPath() string {
 	return config.staticPath
@@ -3,4 +3,13 @@ package config
 // Gcp config stores all gcp related config for a project
 type Gcp struct {
 	ProjectId string
+	// ProjectIds lists more projects to manage resources in
+	ProjectIds []string
+	// Folders and Organizations add all active projects under the folders or organizations, including projects
+	// in folders within them. They are IDs e.g 123456789012
+	Folders       []string
+	Organizations []string
+	// Regions limits Compute Engine instances and GKE clusters to regions or zones e.g us-east1, europe-west1-b. They
+	// can contain wildcards e.g europe-*. All regions are used when it is empty
+	Regions []string
 }
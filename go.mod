@@ -10,13 +10,17 @@ require (
 	github.com/aws/aws-sdk-go-v2/feature/s3/manager v0.2.0
 	github.com/aws/aws-sdk-go-v2/service/ec2 v0.31.0
 	github.com/aws/aws-sdk-go-v2/service/eks v0.31.0
+	github.com/aws/aws-sdk-go-v2/service/organizations v0.31.0
 	github.com/aws/aws-sdk-go-v2/service/rds v0.31.0
 	github.com/aws/aws-sdk-go-v2/service/s3 v0.31.0
+	github.com/aws/aws-sdk-go-v2/service/sts v0.31.0
 	github.com/gin-gonic/gin v1.6.3
 	github.com/go-co-op/gocron v0.5.0
 	github.com/jinzhu/now v1.1.1
 	github.com/labstack/gommon v0.3.0
+	github.com/mattn/go-isatty v0.0.13 // indirect
 	github.com/mitchellh/go-homedir v1.1.0
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sirupsen/logrus v1.7.0
 	github.com/spf13/cobra v1.1.1
 	github.com/spf13/viper v1.7.1
@@ -0,0 +1,209 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mensaah/reka/provider/types"
+	"github.com/mensaah/reka/resource"
+	"github.com/mensaah/reka/rules"
+)
+
+// Change is a single action reka intends to perform on a resource
+type Change struct {
+	Provider string
+	Manager  string
+	Action   rules.Action
+	// Rule is the name of the rule which produced the action
+	Rule     string
+	Resource *resource.Resource
+}
+
+// Plan holds all the changes reka would make in a single execution. A plan can be written to a file,
+// reviewed and later applied
+type Plan struct {
+	CreatedAt time.Time
+	Changes   []*Change
+}
+
+// New returns an empty plan
+func New() *Plan {
+	return &Plan{CreatedAt: time.Now()}
+}
+
+// Add records action for all resources of a provider
+func (p *Plan) Add(provider string, action rules.Action, resources types.Resources) {
+	for mgrName, resList := range resources {
+		for _, r := range resList {
+			if p.contains(provider, mgrName, r) {
+				continue
+			}
+			change := &Change{
+				Provider: provider,
+				Manager:  mgrName,
+				Action:   action,
+				Resource: r,
+			}
+			if rule := rules.GetMatchingRule(r, action); rule != nil {
+				change.Rule = rule.String()
+			}
+			p.Changes = append(p.Changes, change)
+		}
+	}
+}
+
+func (p *Plan) contains(provider, mgrName string, res *resource.Resource) bool {
+	for _, c := range p.Changes {
+		if c.Provider == provider && c.Manager == mgrName && c.Resource.Is(res) {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty checks if the plan has no changes
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// sortedChanges returns changes ordered by provider, manager and resource
+func (p *Plan) sortedChanges() []*Change {
+	changes := make([]*Change, len(p.Changes))
+	copy(changes, p.Changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Provider != changes[j].Provider {
+			return changes[i].Provider < changes[j].Provider
+		}
+		if changes[i].Manager != changes[j].Manager {
+			return changes[i].Manager < changes[j].Manager
+		}
+		return changes[i].Resource.Key() < changes[j].Resource.Key()
+	})
+	return changes
+}
+
+// Print writes a table of all changes in the plan grouped by provider and manager
+func (p *Plan) Print(out io.Writer) {
+	if p.Empty() {
+		fmt.Fprintln(out, "No changes. Resources are up to date with the rules")
+		return
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	provider := ""
+	for _, c := range p.sortedChanges() {
+		if c.Provider != provider {
+			if provider != "" {
+				fmt.Fprintln(w)
+			}
+			provider = c.Provider
+			fmt.Fprintf(w, "Provider: %s\n", provider)
+			fmt.Fprintln(w, "MANAGER\tRESOURCE\tACCOUNT\tREGION\tSTATUS\tACTION\tRULE")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.Manager, c.Resource.UUID, c.Resource.AccountID, c.Resource.Scope(), c.Resource.Status, c.Action, c.Rule)
+	}
+	w.Flush()
+
+	count := make(map[rules.Action]int)
+	for _, c := range p.Changes {
+		count[c.Action]++
+	}
+	fmt.Fprintf(out, "\nPlan: %d to stop, %d to resume, %d to destroy\n", count[rules.Stop], count[rules.Resume], count[rules.Destroy])
+}
+
+// Write saves the plan to path
+func (p *Plan) Write(path string) error {
+	data, err := json.MarshalIndent(p, "", " ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode plan: %s", err)
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Read loads a plan previously written to path
+func Read(path string) (*Plan, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read plan file %s: %s", path, err)
+	}
+	p := &Plan{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("Invalid plan file %s: %s", path, err)
+	}
+	return p, nil
+}
+
+// Providers returns the names of all providers with changes in the plan
+func (p *Plan) Providers() []string {
+	var names []string
+	for _, c := range p.Changes {
+		if !contains(names, c.Provider) {
+			names = append(names, c.Provider)
+		}
+	}
+	return names
+}
+
+// Verify checks the planned changes of a provider against the current resources of the provider.
+// Changes whose resources no longer exist or whose status or tags have changed since the plan was
+// created are rejected. The current resources of the accepted changes are returned by action
+func (p *Plan) Verify(provider string, current types.Resources) (map[rules.Action]types.Resources, []error) {
+	var errs []error
+	accepted := make(map[rules.Action]types.Resources)
+
+	for _, c := range p.Changes {
+		if c.Provider != provider {
+			continue
+		}
+		res := resource.Find(current[c.Manager], c.Resource)
+		if err := c.drift(res); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, ok := accepted[c.Action]; !ok {
+			accepted[c.Action] = make(types.Resources)
+		}
+		accepted[c.Action][c.Manager] = append(accepted[c.Action][c.Manager], res)
+	}
+	return accepted, errs
+}
+
+// drift returns an error if the current resource differs from the planned resource
+func (c *Change) drift(current *resource.Resource) error {
+	id := fmt.Sprintf("%s.%s/%s", c.Provider, c.Manager, c.Resource.Key())
+	if current == nil {
+		return fmt.Errorf("%s: resource no longer exists", id)
+	}
+	if current.Status != c.Resource.Status {
+		return fmt.Errorf("%s: status changed from %s to %s", id, c.Resource.Status, current.Status)
+	}
+	if !equalTags(current.Tags, c.Resource.Tags) {
+		return fmt.Errorf("%s: tags changed since plan was created", id)
+	}
+	return nil
+}
+
+func equalTags(a, b resource.Tags) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(arr []string, str string) bool {
+	for _, a := range arr {
+		if a == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/resource"
+)
+
+// account is an AWS account resources are managed in
+type account struct {
+	name    string
+	cfg     aws.Config
+	regions []string
+
+	mu sync.Mutex
+	id string
+}
+
+// getID returns the ID of the account. It is fetched on first use and kept once it was fetched, so calls cancelled
+// with ctx are retried
+func (a *account) getID(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.id == "" {
+		id, err := getAccountID(ctx, a.cfg)
+		if err != nil {
+			return "", err
+		}
+		a.id = id
+	}
+	return a.id, nil
+}
+
+// String returns the name of the account, or its ID if it has no name and the ID was fetched
+func (a *account) String() string {
+	if a.name != "" {
+		return a.name
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.id == "" {
+		return "default"
+	}
+	return a.id
+}
+
+// loadAccounts returns the accounts in config and the member accounts of the organization in config. The account
+// of the configured credentials is used when neither is set
+func loadAccounts(ctx context.Context, cfg *config.Config) ([]*account, error) {
+	accountsCfg := cfg.AwsOptions.Accounts
+	org := cfg.AwsOptions.Organization
+	if len(accountsCfg) == 0 && org == nil {
+		accountsCfg = []config.AwsAccount{{}}
+	}
+
+	var accts []*account
+	for _, acctCfg := range accountsCfg {
+		acct, err := newAccount(ctx, cfg, acctCfg)
+		if err != nil {
+			return nil, err
+		}
+		accts = append(accts, acct)
+	}
+	if org == nil {
+		return accts, nil
+	}
+
+	members, err := getOrganizationAccounts(ctx, *cfg.Aws, org)
+	if err != nil {
+		return nil, err
+	}
+	// Accounts listed in config take precedence over accounts discovered in the organization. They are compared by
+	// ID as listed accounts may use a profile or the default credentials instead of a role
+	listed := make(map[string]bool)
+	for _, a := range accts {
+		id, err := a.getID(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Account %s: %s", a, err)
+		}
+		listed[id] = true
+	}
+	for _, m := range members {
+		if listed[m.id] {
+			continue
+		}
+		acct, err := newAccount(ctx, cfg, m.AwsAccount)
+		if err != nil {
+			return nil, err
+		}
+		acct.id = m.id
+		accts = append(accts, acct)
+	}
+	return accts, nil
+}
+
+// newAccount returns the account for acctCfg with the regions it is managed in
+func newAccount(ctx context.Context, cfg *config.Config, acctCfg config.AwsAccount) (*account, error) {
+	awsCfg, err := config.LoadAwsAccountConfig(*cfg.Aws, acctCfg)
+	if err != nil {
+		return nil, fmt.Errorf("Account %s: %s", acctCfg.Name, err)
+	}
+	acctRegions := acctCfg.Regions
+	if len(acctRegions) == 0 {
+		acctRegions = cfg.AwsOptions.Regions
+	}
+	acct := &account{name: acctCfg.Name, cfg: awsCfg}
+	if acct.regions, err = getRegions(ctx, awsCfg, acctRegions); err != nil {
+		return nil, fmt.Errorf("Account %s: %s", acct, err)
+	}
+	return acct, nil
+}
+
+// discoverAccounts loads the accounts again, so accounts added to the organization since the provider was created
+// are managed. It must not be called while resources are listed or acted on
+func discoverAccounts(ctx context.Context, logger *log.Entry) error {
+	accts, err := loadAccounts(ctx, config.GetConfig())
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, a := range accounts {
+		known[a.id] = true
+	}
+	for _, a := range accts {
+		if id, err := a.getID(ctx); err == nil && !known[id] {
+			logger.Infof("Managing resources of account %s in regions %s", a, strings.Join(a.regions, ", "))
+		}
+	}
+	accounts = accts
+	return nil
+}
+
+// getAccountIDs returns the IDs of all accounts joined by commas
+func getAccountIDs(ctx context.Context) (string, error) {
+	var ids []string
+	for _, a := range accounts {
+		id, err := a.getID(ctx)
+		if err != nil {
+			return "", fmt.Errorf("Account %s: %s", a.name, err)
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ","), nil
+}
+
+// getAccount returns the account with id. Resources tracked before accounts were recorded have no account ID and
+// belong to the only account if a single account is configured
+func getAccount(ctx context.Context, id string) *account {
+	if id == "" && len(accounts) == 1 {
+		return accounts[0]
+	}
+	for _, a := range accounts {
+		if aID, err := a.getID(ctx); err == nil && aID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// getAllInAccounts calls getAll concurrently for every account of accts and stamps the resources found with the ID
+// of their account
+func getAllInAccounts(ctx context.Context, accts []*account, getAll func(context.Context, *account) ([]*resource.Resource, error)) ([]*resource.Resource, error) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		resources []*resource.Resource
+		errs      = make(scopedErrors)
+	)
+	for _, a := range accts {
+		wg.Add(1)
+		go func(a *account) {
+			defer wg.Done()
+			id, err := a.getID(ctx)
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs[a.String()] = err
+				return
+			}
+			res, err := getAll(ctx, a)
+			for _, r := range res {
+				r.AccountID = id
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[a.String()] = err
+			}
+			resources = append(resources, res...)
+		}(a)
+	}
+	wg.Wait()
+	return resources, errs.err()
+}
+
+// getAllResources calls getAll concurrently for every region of every account
+func getAllResources(ctx context.Context, getAll func(context.Context, aws.Config) ([]*resource.Resource, error)) ([]*resource.Resource, error) {
+	return getAllInAccounts(ctx, accounts, func(ctx context.Context, a *account) ([]*resource.Resource, error) {
+		return getAllInRegions(ctx, a.cfg, a.regions, getAll)
+	})
+}
+
+// getResourcesIn calls getAll concurrently for only the accounts and regions resources are in
+func getResourcesIn(ctx context.Context, resources []*resource.Resource, getAll func(context.Context, aws.Config) ([]*resource.Resource, error)) ([]*resource.Resource, error) {
+	accts, regions := accountsOf(ctx, resources)
+	return getAllInAccounts(ctx, accts, func(ctx context.Context, a *account) ([]*resource.Resource, error) {
+		return getAllInRegions(ctx, a.cfg, regions[a], getAll)
+	})
+}
+
+// accountsOf returns the configured accounts resources belong to and the regions of the resources in each account
+func accountsOf(ctx context.Context, resources []*resource.Resource) ([]*account, map[*account][]string) {
+	var accts []*account
+	regions := make(map[*account][]string)
+	for _, r := range resources {
+		a := getAccount(ctx, r.AccountID)
+		if a == nil {
+			continue
+		}
+		if _, ok := regions[a]; !ok {
+			accts = append(accts, a)
+		}
+		if !config.Contains(regions[a], r.Region) {
+			regions[a] = append(regions[a], r.Region)
+		}
+	}
+	return accts, regions
+}
+
+// actOnResources groups resources by account and calls action concurrently for every region of each account
+// with the resources in the region
+func actOnResources(ctx context.Context, resources []*resource.Resource, results *resource.Results, action actionFunc) error {
+	byAccount := make(map[string][]*resource.Resource)
+	for _, r := range resources {
+		byAccount[r.AccountID] = append(byAccount[r.AccountID], r)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(scopedErrors)
+	)
+	for id, res := range byAccount {
+		a := getAccount(ctx, id)
+		if a == nil {
+			err := fmt.Errorf("Account is not configured")
+			results.Complete(res, err, time.Now())
+			mu.Lock()
+			errs[id] = err
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(a *account, res []*resource.Resource) {
+			defer wg.Done()
+			if err := actInRegions(ctx, a.cfg, res, results, action); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs[a.String()] = err
+			}
+		}(a, res)
+	}
+	wg.Wait()
+	return errs.err()
+}
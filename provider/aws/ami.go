@@ -2,6 +2,8 @@ package aws
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -18,12 +20,15 @@ func getImageDetails(svc *ec2.Client, output *ec2.DescribeImagesOutput, region s
 		for _, t := range image.Tags {
 			tags[*t.Key] = *t.Value
 		}
-		tags["creation-date"] = *image.CreationDate
-		amiResource := NewResource(*image.ImageId, ec2Name)
-		amiResource.Region = *image.ImageLocation
-		// Get CreationDate by getting LaunchTime of attached Image
-		amiLogger.Debugf("TIME: %s", *image.CreationDate)
-		// amiResource.CreationDate = *image.CreationDate
+		tags["creation-date"] = aws.ToString(image.CreationDate)
+		amiResource := NewResource(*image.ImageId, amiName)
+		amiResource.Region = region
+		// The creation date of images is a string in RFC3339
+		creationDate, err := time.Parse(time.RFC3339, aws.ToString(image.CreationDate))
+		if err != nil {
+			amiLogger.Errorf("Could not parse creation date for image %s, value %s", *image.ImageId, aws.ToString(image.CreationDate))
+		}
+		amiResource.CreationDate = creationDate
 		amiResource.Tags = tags
 		amiResource.Status = resource.Running
 		images = append(images, amiResource)
@@ -32,7 +37,7 @@ func getImageDetails(svc *ec2.Client, output *ec2.DescribeImagesOutput, region s
 }
 
 // GetAllImages Get all images
-func GetAllImages(cfg aws.Config) ([]*resource.Resource, error) {
+func GetAllImages(ctx context.Context, cfg aws.Config) ([]*resource.Resource, error) {
 	amiLogger.Debug("Fetching images...")
 
 	svc := ec2.NewFromConfig(cfg)
@@ -40,8 +45,8 @@ func GetAllImages(cfg aws.Config) ([]*resource.Resource, error) {
 		Owners: []string{"self"},
 	}
 
-	// Build the request with its input parameters
-	resp, err := svc.DescribeImages(context.TODO(), params)
+	// DescribeImages is not paginated and returns all images in one call
+	resp, err := svc.DescribeImages(ctx, params)
 
 	if err != nil {
 		return nil, err
@@ -55,31 +60,37 @@ func GetAllImages(cfg aws.Config) ([]*resource.Resource, error) {
 }
 
 // Terminatemages deregisters images
-func TerminateImages(cfg aws.Config, images []*resource.Resource) error {
+func TerminateImages(ctx context.Context, cfg aws.Config, images []*resource.Resource, results *resource.Results) error {
 	svc := ec2.NewFromConfig(cfg)
-	var imageIds []string
+	var targetImages []*resource.Resource
 
 	for _, image := range images {
 		if image.IsStopped() || image.IsActive() {
-			imageIds = append(imageIds, image.UUID)
+			targetImages = append(targetImages, image)
+		} else {
+			results.Skipped(image, fmt.Sprintf("Image is %s", image.Status))
 		}
 	}
 
-	if len(imageIds) <= 0 {
+	if len(targetImages) <= 0 {
 		return nil
 	}
 
-	amiLogger.Debug("Terminating Ami Images ", imageIds, " ...")
+	amiLogger.Debug("Terminating Ami Images ", targetImages, " ...")
 
-	for _, imageId := range imageIds {
+	for _, image := range targetImages {
+		start := time.Now()
 		params := &ec2.DeregisterImageInput{
-			ImageId: &imageId,
+			ImageId: &image.UUID,
 		}
 
-		_, err := svc.DeregisterImage(context.TODO(), params)
+		_, err := svc.DeregisterImage(ctx, params)
 		if err != nil {
-			amiLogger.Errorf("Failed to Deregistering Image %s: %s", imageId, err)
+			amiLogger.Errorf("Failed to Deregistering Image %s: %s", image.UUID, err)
+			results.Failed(image, err, start)
+			continue
 		}
+		results.Succeeded(image, start)
 	}
 	return nil
 }
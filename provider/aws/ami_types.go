@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -29,11 +31,14 @@ func newAmiManager(cfg *config.Config, logPath string) resource.Manager {
 		LongName: amiLongName,
 		Config:   cfg,
 		Logger:   logger,
-		GetAll: func() ([]*resource.Resource, error) {
-			return GetAllImages(*cfg.Aws)
+		GetAll: func(ctx context.Context) ([]*resource.Resource, error) {
+			return getAllResources(ctx, GetAllImages)
+		},
+		GetAllIn: func(ctx context.Context, resources []*resource.Resource) ([]*resource.Resource, error) {
+			return getResourcesIn(ctx, resources, GetAllImages)
 		},
-		Destroy: func(resources []*resource.Resource) error {
-			return TerminateImages(*cfg.Aws, resources)
+		Destroy: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, TerminateImages)
 		},
 	}
 	return amiManager
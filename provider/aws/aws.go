@@ -1,6 +1,11 @@
 package aws
 
 import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -12,6 +17,10 @@ var (
 	providerName     = "aws"
 	logger           *log.Entry
 	resourceManagers map[string]*resource.Manager
+	// accounts resources are managed in
+	accounts []*account
+	// limiter limits the number of regions resources are listed in or acted on at once
+	limiter types.Limiter
 )
 
 func GetName() string {
@@ -24,12 +33,18 @@ func NewResource(id, manager string) *resource.Resource {
 	resource.UUID = id
 	resource.Manager = resourceManagers[manager]
 	resource.ProviderName = providerName
+	resource.Attributes = make(map[string]interface{})
 
 	return &resource
 }
 
+// pageSize returns the configured page size for an API which allows pages of min to max results
+func pageSize(min, max int32) int32 {
+	return types.PageSize(min, max)
+}
+
 // NewProvider : Creates a New AWS Provider
-func NewProvider() (*types.Provider, error) {
+func NewProvider(ctx context.Context) (*types.Provider, error) {
 
 	aws := types.Provider{}
 	aws.Name = providerName
@@ -37,6 +52,14 @@ func NewProvider() (*types.Provider, error) {
 	aws.SetLogger("logger.log")
 
 	cfg := config.GetConfig()
+	limiter = types.NewLimiter(cfg.Concurrency)
+	var err error
+	if accounts, err = loadAccounts(ctx, cfg); err != nil {
+		return nil, err
+	}
+	for _, a := range accounts {
+		aws.Logger.Infof("Managing resources of account %s in regions %s", a, strings.Join(a.regions, ", "))
+	}
 
 	ec2Manager := newEC2Manager(cfg, aws.LogPath)
 	eksManager := newEksManager(cfg, aws.LogPath)
@@ -57,5 +80,26 @@ func NewProvider() (*types.Provider, error) {
 	}
 
 	aws.Managers = resourceManagers
+	aws.AccountID = getAccountIDs
+	if cfg.AwsOptions.Organization != nil {
+		aws.Discover = func(ctx context.Context) error {
+			return discoverAccounts(ctx, aws.Logger)
+		}
+	}
 	return &aws, nil
 }
+
+// getAccountID returns the ID of the account the credentials belong to
+func getAccountID(ctx context.Context, cfg aws.Config) (string, error) {
+	resp, err := getCallerIdentity(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	return *resp.Account, nil
+}
+
+// getCallerIdentity returns the account and ARN the credentials belong to
+func getCallerIdentity(ctx context.Context, cfg aws.Config) (*sts.GetCallerIdentityOutput, error) {
+	svc := sts.NewFromConfig(cfg)
+	return svc.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+}
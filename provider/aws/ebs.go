@@ -2,6 +2,8 @@ package aws
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -22,7 +24,7 @@ func getVolumeDetails(svc *ec2.Client, output *ec2.DescribeVolumesOutput, region
 		// Ebs Volumes Launch Time is not the creation date. It's the time it was last launched.
 		// TODO To get the creation date we might want to get the creation date of the EBS attached to the Ebs instead
 		tags["creation-date"] = (*volume.CreateTime).String()
-		ebsResource := NewResource(*volume.VolumeId, ec2Name)
+		ebsResource := NewResource(*volume.VolumeId, ebsName)
 		ebsResource.Region = region
 		// Get CreationDate by getting LaunchTime of attached Volume
 		ebsResource.CreationDate = *volume.CreateTime
@@ -38,53 +40,63 @@ func getVolumeDetails(svc *ec2.Client, output *ec2.DescribeVolumesOutput, region
 }
 
 // GetAllEbsVolumes Get all volumes
-func GetAllEbsVolumes(cfg aws.Config) ([]*resource.Resource, error) {
+func GetAllEbsVolumes(ctx context.Context, cfg aws.Config) ([]*resource.Resource, error) {
 	ebsLogger.Debug("Fetching Ebs Volumes")
 
 	svc := ec2.NewFromConfig(cfg)
 	params := &ec2.DescribeVolumesInput{}
 
-	// Build the request with its input parameters
-	resp, err := svc.DescribeVolumes(context.TODO(), params)
-
-	if err != nil {
-		return nil, err
-	}
-	volumes, err := getVolumeDetails(svc, resp, cfg.Region)
-	if err != nil {
-		return nil, err
+	var volumes []*resource.Resource
+	p := ec2.NewDescribeVolumesPaginator(svc, params, func(o *ec2.DescribeVolumesPaginatorOptions) {
+		o.Limit = pageSize(5, 500)
+	})
+	for p.HasMorePages() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pageVolumes, err := getVolumeDetails(svc, resp, cfg.Region)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, pageVolumes...)
 	}
 	ebsLogger.Debugf("Found %d Ebs volumes", len(volumes))
 	return volumes, nil
 }
 
 // TerminateEbsVolumes Shutdown volumes
-func TerminateEbsVolumes(cfg aws.Config, volumes []*resource.Resource) error {
+func TerminateEbsVolumes(ctx context.Context, cfg aws.Config, volumes []*resource.Resource, results *resource.Results) error {
 	svc := ec2.NewFromConfig(cfg)
-	var volumeIds []string
+	var targetVolumes []*resource.Resource
 
 	for _, volume := range volumes {
 		if volume.IsStopped() || volume.IsActive() {
-			volumeIds = append(volumeIds, volume.UUID)
+			targetVolumes = append(targetVolumes, volume)
+		} else {
+			results.Skipped(volume, fmt.Sprintf("Volume is %s", volume.Status))
 		}
 	}
 
-	if len(volumeIds) <= 0 {
+	if len(targetVolumes) <= 0 {
 		return nil
 	}
 
-	ebsLogger.Debug("Terminating Ebs Volumes ", volumeIds, " ...")
+	ebsLogger.Debug("Terminating Ebs Volumes ", targetVolumes, " ...")
 
-	for _, volumeId := range volumeIds {
+	for _, volume := range targetVolumes {
+		start := time.Now()
 		params := &ec2.DeleteVolumeInput{
-			VolumeId: &volumeId,
+			VolumeId: &volume.UUID,
 		}
 
-		_, err := svc.DeleteVolume(context.TODO(), params)
-		// TODO Attach error to specific volume where the error occurred if possible
+		_, err := svc.DeleteVolume(ctx, params)
 		if err != nil {
-			ebsLogger.Errorf("Failed to Delete VOlume %s: %s", volumeId, err)
+			ebsLogger.Errorf("Failed to Delete VOlume %s: %s", volume.UUID, err)
+			results.Failed(volume, err, start)
+			continue
 		}
+		results.Succeeded(volume, start)
 	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -29,11 +31,14 @@ func newEbsManager(cfg *config.Config, logPath string) resource.Manager {
 		LongName: ebsLongName,
 		Config:   cfg,
 		Logger:   logger,
-		GetAll: func() ([]*resource.Resource, error) {
-			return GetAllEbsVolumes(*cfg.Aws)
+		GetAll: func(ctx context.Context) ([]*resource.Resource, error) {
+			return getAllResources(ctx, GetAllEbsVolumes)
+		},
+		GetAllIn: func(ctx context.Context, resources []*resource.Resource) ([]*resource.Resource, error) {
+			return getResourcesIn(ctx, resources, GetAllEbsVolumes)
 		},
-		Destroy: func(resources []*resource.Resource) error {
-			return TerminateEbsVolumes(*cfg.Aws, resources)
+		Destroy: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, TerminateEbsVolumes)
 		},
 	}
 	return ebsManager
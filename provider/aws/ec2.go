@@ -39,34 +39,41 @@ func getInstanceDetails(svc *ec2.Client, output *ec2.DescribeInstancesOutput, re
 }
 
 // GetAllEC2Instances Get all instances
-func GetAllEC2Instances(cfg aws.Config) ([]*resource.Resource, error) {
+func GetAllEC2Instances(ctx context.Context, cfg aws.Config) ([]*resource.Resource, error) {
 	ec2Logger.Debug("Fetching EC2 Instances")
 
 	svc := ec2.NewFromConfig(cfg)
 	params := &ec2.DescribeInstancesInput{}
 
-	// Build the request with its input parameters
-	resp, err := svc.DescribeInstances(context.TODO(), params)
-
-	if err != nil {
-		return nil, err
-	}
-	instances, err := getInstanceDetails(svc, resp, cfg.Region)
-	if err != nil {
-		return nil, err
+	var instances []*resource.Resource
+	p := ec2.NewDescribeInstancesPaginator(svc, params, func(o *ec2.DescribeInstancesPaginatorOptions) {
+		o.Limit = pageSize(5, 1000)
+	})
+	for p.HasMorePages() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pageInstances, err := getInstanceDetails(svc, resp, cfg.Region)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, pageInstances...)
 	}
 	ec2Logger.Debugf("Found %d EC2 instances", len(instances))
 	return instances, nil
 }
 
 // StopEC2Instances Stop Running Instances
-func StopEC2Instances(cfg aws.Config, instances []*resource.Resource) error {
+func StopEC2Instances(ctx context.Context, cfg aws.Config, instances []*resource.Resource, results *resource.Results) error {
 	svc := ec2.NewFromConfig(cfg)
 	var instanceIds []string
 
 	for _, instance := range instances {
 		if instance.IsActive() {
 			instanceIds = append(instanceIds, instance.UUID)
+		} else {
+			results.Skipped(instance, fmt.Sprintf("Instance is %s", instance.Status))
 		}
 	}
 
@@ -80,22 +87,21 @@ func StopEC2Instances(cfg aws.Config, instances []*resource.Resource) error {
 		InstanceIds: instanceIds,
 	}
 
-	resp, err := svc.StopInstances(context.TODO(), params)
-	// TODO Attach error to specific instance where the error occurred if possible
-	if err != nil {
-		fmt.Println(resp, err)
-	}
+	_, err := svc.StopInstances(ctx, params)
+	// The instances of a call fail or succeed together
 	return err
 }
 
 // ResumeEC2Instances Resume Stopped instances
-func ResumeEC2Instances(cfg aws.Config, instances []*resource.Resource) error {
+func ResumeEC2Instances(ctx context.Context, cfg aws.Config, instances []*resource.Resource, results *resource.Results) error {
 	svc := ec2.NewFromConfig(cfg)
 	var instanceIds []string
 
 	for _, instance := range instances {
 		if instance.IsStopped() {
 			instanceIds = append(instanceIds, instance.UUID)
+		} else {
+			results.Skipped(instance, fmt.Sprintf("Instance is %s", instance.Status))
 		}
 	}
 
@@ -108,22 +114,21 @@ func ResumeEC2Instances(cfg aws.Config, instances []*resource.Resource) error {
 	}
 	ec2Logger.Debug("Starting EC2 Instances ", instanceIds, " ...")
 
-	resp, err := svc.StartInstances(context.TODO(), params)
-	// TODO Attach error to specific instance where the error occurred if possible
-	if err != nil {
-		fmt.Println(resp, err)
-	}
+	_, err := svc.StartInstances(ctx, params)
+	// The instances of a call fail or succeed together
 	return err
 }
 
 // TerminateEC2Instances Shutdown instances
-func TerminateEC2Instances(cfg aws.Config, instances []*resource.Resource) error {
+func TerminateEC2Instances(ctx context.Context, cfg aws.Config, instances []*resource.Resource, results *resource.Results) error {
 	svc := ec2.NewFromConfig(cfg)
 	var instanceIds []string
 
 	for _, instance := range instances {
 		if instance.IsStopped() || instance.IsActive() {
 			instanceIds = append(instanceIds, instance.UUID)
+		} else {
+			results.Skipped(instance, fmt.Sprintf("Instance is %s", instance.Status))
 		}
 	}
 
@@ -137,10 +142,7 @@ func TerminateEC2Instances(cfg aws.Config, instances []*resource.Resource) error
 		InstanceIds: instanceIds,
 	}
 
-	resp, err := svc.TerminateInstances(context.TODO(), params)
-	// TODO Attach error to specific instance where the error occurred if possible
-	if err != nil {
-		fmt.Println(resp, err)
-	}
+	_, err := svc.TerminateInstances(ctx, params)
+	// The instances of a call fail or succeed together
 	return err
 }
@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -29,17 +31,20 @@ func newEC2Manager(cfg *config.Config, logPath string) resource.Manager {
 		LongName: ec2LongName,
 		Config:   cfg,
 		Logger:   logger,
-		GetAll: func() ([]*resource.Resource, error) {
-			return GetAllEC2Instances(*cfg.Aws)
+		GetAll: func(ctx context.Context) ([]*resource.Resource, error) {
+			return getAllResources(ctx, GetAllEC2Instances)
+		},
+		GetAllIn: func(ctx context.Context, resources []*resource.Resource) ([]*resource.Resource, error) {
+			return getResourcesIn(ctx, resources, GetAllEC2Instances)
 		},
-		Destroy: func(resources []*resource.Resource) error {
-			return TerminateEC2Instances(*cfg.Aws, resources)
+		Destroy: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, TerminateEC2Instances)
 		},
-		Stop: func(resources []*resource.Resource) error {
-			return StopEC2Instances(*cfg.Aws, resources)
+		Stop: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, StopEC2Instances)
 		},
-		Resume: func(resources []*resource.Resource) error {
-			return ResumeEC2Instances(*cfg.Aws, resources)
+		Resume: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, ResumeEC2Instances)
 		},
 	}
 	return ec2Manager
@@ -2,6 +2,8 @@ package aws
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -32,14 +34,14 @@ func getIPDetails(svc *ec2.Client, output *ec2.DescribeAddressesOutput, region s
 }
 
 // GetAllIPAddresses Get all ips
-func GetAllIPAddresses(cfg aws.Config) ([]*resource.Resource, error) {
+func GetAllIPAddresses(ctx context.Context, cfg aws.Config) ([]*resource.Resource, error) {
 	eipLogger.Debug("Fetching EIp Ips")
 
 	svc := ec2.NewFromConfig(cfg)
 	params := &ec2.DescribeAddressesInput{}
 
-	// Build the request with its input parameters
-	resp, err := svc.DescribeAddresses(context.TODO(), params)
+	// DescribeAddresses is not paginated and returns all addresses in one call
+	resp, err := svc.DescribeAddresses(ctx, params)
 
 	if err != nil {
 		return nil, err
@@ -53,13 +55,15 @@ func GetAllIPAddresses(cfg aws.Config) ([]*resource.Resource, error) {
 }
 
 // TerminateIPAddresses Shutdown ips
-func TerminateIPAddresses(cfg aws.Config, ips []*resource.Resource) error {
+func TerminateIPAddresses(ctx context.Context, cfg aws.Config, ips []*resource.Resource, results *resource.Results) error {
 	svc := ec2.NewFromConfig(cfg)
 	var targetIps []*resource.Resource
 
 	for _, ip := range ips {
 		if ip.IsStopped() || ip.IsActive() {
 			targetIps = append(targetIps, ip)
+		} else {
+			results.Skipped(ip, fmt.Sprintf("Address is %s", ip.Status))
 		}
 	}
 
@@ -70,23 +74,28 @@ func TerminateIPAddresses(cfg aws.Config, ips []*resource.Resource) error {
 	eipLogger.Debug("Terminating Ips ", targetIps, " ...")
 
 	for _, ip := range targetIps {
+		start := time.Now()
 		if ip.Status != resource.Unused {
 			params := &ec2.DisassociateAddressInput{
 				AssociationId: &ip.UUID,
 			}
-			_, err := svc.DisassociateAddress(context.TODO(), params)
+			_, err := svc.DisassociateAddress(ctx, params)
 			if err != nil {
 				eipLogger.Errorf("Failed to Dissociated IP %s: %s", ip.UUID, err)
+				results.Failed(ip, err, start)
 				continue
 			}
 		}
 		params := &ec2.ReleaseAddressInput{
 			AllocationId: &ip.UUID,
 		}
-		_, err := svc.ReleaseAddress(context.TODO(), params)
+		_, err := svc.ReleaseAddress(ctx, params)
 		if err != nil {
 			eipLogger.Errorf("Failed to Delete VOlume %s: %s", ip.UUID, err)
+			results.Failed(ip, err, start)
+			continue
 		}
+		results.Succeeded(ip, start)
 	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -27,11 +29,14 @@ func newEipManager(cfg *config.Config, logPath string) resource.Manager {
 		LongName: eipLongName,
 		Config:   cfg,
 		Logger:   logger,
-		GetAll: func() ([]*resource.Resource, error) {
-			return GetAllIPAddresses(*cfg.Aws)
+		GetAll: func(ctx context.Context) ([]*resource.Resource, error) {
+			return getAllResources(ctx, GetAllIPAddresses)
+		},
+		GetAllIn: func(ctx context.Context, resources []*resource.Resource) ([]*resource.Resource, error) {
+			return getResourcesIn(ctx, resources, GetAllIPAddresses)
 		},
-		Destroy: func(resources []*resource.Resource) error {
-			return TerminateIPAddresses(*cfg.Aws, resources)
+		Destroy: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, TerminateIPAddresses)
 		},
 	}
 	return eipManager
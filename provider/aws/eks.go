@@ -2,6 +2,9 @@ package aws
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
@@ -11,16 +14,23 @@ import (
 	"github.com/mensaah/reka/resource"
 )
 
-func getNodegroupDetails(svc *eks.Client, clusterName string) ([]*resource.Resource, error) {
+func getNodegroupDetails(ctx context.Context, svc *eks.Client, clusterName string) ([]*resource.Resource, error) {
 	var nodegroups []*resource.Resource
 	params := &eks.ListNodegroupsInput{ClusterName: &clusterName}
-	ngList, err := svc.ListNodegroups(context.TODO(), params)
-	if err != nil {
-		return []*resource.Resource{}, err
+	var ngNames []string
+	p := eks.NewListNodegroupsPaginator(svc, params, func(o *eks.ListNodegroupsPaginatorOptions) {
+		o.Limit = pageSize(1, 100)
+	})
+	for p.HasMorePages() {
+		ngList, err := p.NextPage(ctx)
+		if err != nil {
+			return []*resource.Resource{}, err
+		}
+		ngNames = append(ngNames, ngList.Nodegroups...)
 	}
-	for _, i := range ngList.Nodegroups {
+	for _, i := range ngNames {
 		params := &eks.DescribeNodegroupInput{ClusterName: &clusterName, NodegroupName: &i}
-		resp, err := svc.DescribeNodegroup(context.TODO(), params)
+		resp, err := svc.DescribeNodegroup(ctx, params)
 		if err != nil {
 			return []*resource.Resource{}, err
 		}
@@ -30,39 +40,46 @@ func getNodegroupDetails(svc *eks.Client, clusterName string) ([]*resource.Resou
 
 		ngResource := NewResource(i, nodegroupName)
 		ngResource.CreationDate = *resp.Nodegroup.CreatedAt
-		ngResource.Attributes["DesiredSize"] = resp.Nodegroup.ScalingConfig.DesiredSize
+		ngResource.Attributes["DesiredSize"] = aws.ToInt32(resp.Nodegroup.ScalingConfig.DesiredSize)
 		ngResource.Status = utils.GetEksResourceStatus(string(resp.Nodegroup.Status))
 		nodegroups = append(nodegroups, ngResource)
 	}
 	return nodegroups, nil
 }
 
-func resizeNodeGroup(svc *eks.Client, clusterName string, ngName string, size int32) error {
+func resizeNodeGroup(ctx context.Context, svc *eks.Client, clusterName string, ngName string, size int32) error {
 	params := &eks.UpdateNodegroupConfigInput{
 		ClusterName:   &clusterName,
 		NodegroupName: &ngName,
 		ScalingConfig: &types.NodegroupScalingConfig{DesiredSize: &size},
 	}
-	_, err := svc.UpdateNodegroupConfig(context.TODO(), params)
+	_, err := svc.UpdateNodegroupConfig(ctx, params)
 	return err
 }
 
-func getClusterDetails(svc *eks.Client, output *eks.ListClustersOutput) []*resource.Resource {
+func getClusterDetails(ctx context.Context, svc *eks.Client, output *eks.ListClustersOutput, region string) ([]*resource.Resource, error) {
 	var eksClusters []*resource.Resource
 	eksLogger.Debug("Fetching EKS Details")
 	for _, c := range output.Clusters {
 		clusterDetailsInput := &eks.DescribeClusterInput{Name: &c}
 		// Build the request with its input parameters
-		resp, err := svc.DescribeCluster(context.TODO(), clusterDetailsInput)
+		resp, err := svc.DescribeCluster(ctx, clusterDetailsInput)
 		if err != nil {
-			eksLogger.Errorf("Failed to get details for cluster %s: %s", c, err)
-			continue
+			if isEKSNotFound(err) {
+				eksLogger.Debugf("Cluster %s was deleted while listing clusters", c)
+				continue
+			}
+			// Clusters missing from a listing are taken as destroyed, so the listing fails instead
+			return nil, fmt.Errorf("failed to get details for cluster %s: %w", c, err)
 		}
 		cluster := resp.Cluster
-		nodeGroups, err := getNodegroupDetails(svc, *cluster.Name)
+		nodeGroups, err := getNodegroupDetails(ctx, svc, *cluster.Name)
 		if err != nil {
-			eksLogger.Errorf("Failed to get nodegroup for cluster %s: %s", c, err)
-			continue
+			if isEKSNotFound(err) {
+				eksLogger.Debugf("Cluster %s was deleted while listing clusters", c)
+				continue
+			}
+			return nil, fmt.Errorf("failed to get nodegroups of cluster %s: %w", c, err)
 		}
 
 		// https://stackoverflow.com/a/48554123/7167357
@@ -70,6 +87,7 @@ func getClusterDetails(svc *eks.Client, output *eks.ListClustersOutput) []*resou
 		tags["creation-date"] = (*cluster.CreatedAt).String()
 
 		eksResource := NewResource(*cluster.Name, eksName)
+		eksResource.Region = region
 		// Get CreationDate by getting LaunchTime of attached Volume
 		eksResource.CreationDate = *cluster.CreatedAt
 		eksResource.SubResources = make(map[string][]*resource.Resource)
@@ -79,109 +97,164 @@ func getClusterDetails(svc *eks.Client, output *eks.ListClustersOutput) []*resou
 		eksClusters = append(eksClusters, eksResource)
 	}
 
-	return eksClusters
+	return eksClusters, nil
+}
+
+// isEKSNotFound checks if err is returned for a cluster or nodegroup which does not exist
+func isEKSNotFound(err error) bool {
+	var notFound *types.ResourceNotFoundException
+	return errors.As(err, &notFound)
 }
 
 // GetAllEKSClusters Get all clusters
-func GetAllEKSClusters(cfg aws.Config) ([]*resource.Resource, error) {
+func GetAllEKSClusters(ctx context.Context, cfg aws.Config) ([]*resource.Resource, error) {
 	eksLogger.Debug("Fetching EKS Clusters")
 
 	svc := eks.NewFromConfig(cfg)
 	params := &eks.ListClustersInput{}
 
-	// Build the request with its input parameters
-	resp, err := svc.ListClusters(context.TODO(), params)
-
-	if err != nil {
-		return nil, err
+	var clusters []*resource.Resource
+	p := eks.NewListClustersPaginator(svc, params, func(o *eks.ListClustersPaginatorOptions) {
+		o.Limit = pageSize(1, 100)
+	})
+	for p.HasMorePages() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pageClusters, err := getClusterDetails(ctx, svc, resp, cfg.Region)
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, pageClusters...)
 	}
-	clusters := getClusterDetails(svc, resp)
 	eksLogger.Debugf("Found %d EKS clusters", len(clusters))
 	return clusters, nil
 }
 
 // StopEKSClusters Stop Running Clusters
-func StopEKSClusters(cfg aws.Config, clusters []*resource.Resource) error {
+func StopEKSClusters(ctx context.Context, cfg aws.Config, clusters []*resource.Resource, results *resource.Results) error {
 	svc := eks.NewFromConfig(cfg)
-	var stoppableClusters []*resource.Resource
 
-	for _, cluster := range clusters {
-		if cluster.IsActive() {
-			stoppableClusters = append(stoppableClusters, cluster)
+	for _, clsr := range clusters {
+		if !clsr.IsActive() {
+			results.Skipped(clsr, fmt.Sprintf("Cluster is %s", clsr.Status))
+			continue
 		}
-	}
-
-	if len(clusters) <= 0 {
-		return nil
-	}
-
-	for _, clsr := range stoppableClusters {
+		start := time.Now()
 		eksLogger.Debugf("Stopping EKS Clusters %s ...", clsr)
+		var err error
 		for _, ng := range clsr.SubResources[nodegroupName] {
-			err := resizeNodeGroup(svc, clsr.UUID, ng.UUID, 0)
-			if err != nil {
-				eksLogger.Errorf("Failed Stopping Nodegroup %s in cluster %s: %s", ng, clsr, err)
+			if ngErr := resizeNodeGroup(ctx, svc, clsr.UUID, ng.UUID, 0); ngErr != nil {
+				eksLogger.Errorf("Failed Stopping Nodegroup %s in cluster %s: %s", ng, clsr, ngErr)
+				err = fmt.Errorf("Nodegroup %s: %s", ng.UUID, ngErr)
 			}
 		}
+		if err != nil {
+			results.Failed(clsr, err, start)
+			continue
+		}
+		results.Succeeded(clsr, start)
 	}
 	return nil
 }
 
 // ResumeEKSClusters Resume Stopped clusters
-func ResumeEKSClusters(cfg aws.Config, clusters []*resource.Resource) error {
+func ResumeEKSClusters(ctx context.Context, cfg aws.Config, clusters []*resource.Resource, results *resource.Results) error {
 	svc := eks.NewFromConfig(cfg)
 
-	var resumableClusters []*resource.Resource
-	for _, cluster := range clusters {
-		if cluster.IsStopped() {
-			resumableClusters = append(resumableClusters, cluster)
-		}
-	}
-
-	if len(clusters) <= 0 {
-		return nil
-	}
 	for _, clsr := range clusters {
-		desired, err := utils.GetResourceFromDesiredState(providerName, eksName, clsr.UUID)
+		if !clsr.IsStopped() {
+			results.Skipped(clsr, fmt.Sprintf("Cluster is %s", clsr.Status))
+			continue
+		}
+		start := time.Now()
+		desired, err := utils.GetResourceFromDesiredState(providerName, eksName, clsr)
 		if err != nil {
 			eksLogger.Error(err.Error())
+			results.Failed(clsr, err, start)
 			continue
 		}
-		eksLogger.Debugf("Stopping EKS Clusters %s ...", clsr)
+		eksLogger.Debugf("Resuming EKS Clusters %s ...", clsr)
 		for _, ng := range desired.SubResources[nodegroupName] {
-			desiredSize, _ := ng.Attributes["DesiredSize"].(int32)
-			err := resizeNodeGroup(svc, clsr.UUID, ng.UUID, desiredSize)
-			if err != nil {
-				eksLogger.Errorf("Failed Stopping Nodegroup %s in cluster %s: %s", ng, clsr, err)
+			desiredSize, ok := ng.IntAttribute("DesiredSize")
+			if !ok {
+				err = fmt.Errorf("Nodegroup %s has no desired size in state", ng.UUID)
+				continue
+			}
+			if ngErr := resizeNodeGroup(ctx, svc, clsr.UUID, ng.UUID, int32(desiredSize)); ngErr != nil {
+				eksLogger.Errorf("Failed Resuming Nodegroup %s in cluster %s: %s", ng, clsr, ngErr)
+				err = fmt.Errorf("Nodegroup %s: %s", ng.UUID, ngErr)
 			}
 		}
+		if err != nil {
+			results.Failed(clsr, err, start)
+			continue
+		}
+		results.Succeeded(clsr, start)
 	}
 	return nil
 }
 
 // TerminateEKSClusters Shutdown clusters
-func TerminateEKSClusters(cfg aws.Config, clusters []*resource.Resource) error {
+func TerminateEKSClusters(ctx context.Context, cfg aws.Config, clusters []*resource.Resource, results *resource.Results) error {
 	svc := eks.NewFromConfig(cfg)
 
-	var targetClusters []*resource.Resource
+	eksLogger.Debug("Terminating EKS Clusters ", clusters, " ...")
 	for _, cluster := range clusters {
-		if cluster.IsStopped() || cluster.IsActive() {
-			targetClusters = append(targetClusters, cluster)
+		if !cluster.IsStopped() && !cluster.IsActive() {
+			results.Skipped(cluster, fmt.Sprintf("Cluster is %s", cluster.Status))
+			continue
 		}
-	}
-
-	if len(clusters) <= 0 {
-		return nil
-	}
-	for _, cluster := range targetClusters {
+		start := time.Now()
 		params := &eks.DeleteClusterInput{Name: &cluster.UUID}
-		_, err := svc.DeleteCluster(context.TODO(), params)
+		_, err := svc.DeleteCluster(ctx, params)
 		if err != nil {
 			eksLogger.Errorf("Error Deleting Cluster %s: %s", cluster, err)
+			results.Failed(cluster, err, start)
+			continue
 		}
+		results.Succeeded(cluster, start)
 	}
-
-	eksLogger.Debug("Terminating EKS Clusters ", clusters, " ...")
-
 	return nil
 }
+
+// eksClusterConverged checks if a cluster reached expected. Clusters stay active when they are stopped or resumed, so
+// their nodegroups are checked instead. Stopped nodegroups have no nodes, and resumed nodegroups have the size stored
+// in the desired state
+func eksClusterConverged(cluster *resource.Resource, expected resource.Status) bool {
+	if expected != resource.Stopped && expected != resource.Running {
+		return cluster.Status == expected
+	}
+	if !cluster.IsActive() {
+		return false
+	}
+	var desired []*resource.Resource
+	if expected == resource.Running {
+		d, err := utils.GetResourceFromDesiredState(providerName, eksName, cluster)
+		if err != nil {
+			eksLogger.Error(err)
+			return false
+		}
+		desired = d.SubResources[nodegroupName]
+	}
+	for _, ng := range cluster.SubResources[nodegroupName] {
+		if !ng.IsActive() {
+			return false
+		}
+		size, _ := ng.IntAttribute("DesiredSize")
+		want := int64(0)
+		if expected == resource.Running {
+			d := resource.Find(desired, ng)
+			if d == nil {
+				// Nodegroups created since the cluster was stopped are not resized
+				continue
+			}
+			want, _ = d.IntAttribute("DesiredSize")
+		}
+		if size != want {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -31,18 +33,22 @@ func newEksManager(cfg *config.Config, logPath string) resource.Manager {
 		LongName: eksLongName,
 		Config:   cfg,
 		Logger:   logger,
-		GetAll: func() ([]*resource.Resource, error) {
-			return GetAllEKSClusters(*cfg.Aws)
+		GetAll: func(ctx context.Context) ([]*resource.Resource, error) {
+			return getAllResources(ctx, GetAllEKSClusters)
+		},
+		GetAllIn: func(ctx context.Context, resources []*resource.Resource) ([]*resource.Resource, error) {
+			return getResourcesIn(ctx, resources, GetAllEKSClusters)
 		},
-		Destroy: func(resources []*resource.Resource) error {
-			return TerminateEKSClusters(*cfg.Aws, resources)
+		Destroy: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, TerminateEKSClusters)
 		},
-		Stop: func(resources []*resource.Resource) error {
-			return StopEKSClusters(*cfg.Aws, resources)
+		Stop: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, StopEKSClusters)
 		},
-		Resume: func(resources []*resource.Resource) error {
-			return ResumeEKSClusters(*cfg.Aws, resources)
+		Resume: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, ResumeEKSClusters)
 		},
+		Converged: eksClusterConverged,
 	}
 	return eksManager
 }
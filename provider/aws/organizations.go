@@ -0,0 +1,158 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgTypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+
+	"github.com/mensaah/reka/config"
+)
+
+const defaultOrganizationRole = "OrganizationAccountAccessRole"
+
+// orgAccount is a member account of an organization
+type orgAccount struct {
+	id string
+	config.AwsAccount
+}
+
+// getOrganizationAccounts returns the active member accounts of the organization which match the filters in
+// org. Accounts assume org.RoleName except the account of cfg which uses cfg as it is
+func getOrganizationAccounts(ctx context.Context, cfg aws.Config, org *config.AwsOrganization) ([]orgAccount, error) {
+	svc := organizations.NewFromConfig(cfg)
+	var members []orgTypes.Account
+	if len(org.OrganizationalUnits) == 0 {
+		p := organizations.NewListAccountsPaginator(svc, &organizations.ListAccountsInput{}, func(o *organizations.ListAccountsPaginatorOptions) {
+			o.Limit = pageSize(1, 20)
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("Could not list organization accounts: %s", err)
+			}
+			members = append(members, page.Accounts...)
+		}
+	}
+	for _, ou := range org.OrganizationalUnits {
+		ouMembers, err := listOUAccounts(ctx, svc, ou)
+		if err != nil {
+			return nil, fmt.Errorf("Could not list accounts of %s: %s", ou, err)
+		}
+		members = append(members, ouMembers...)
+	}
+
+	caller, err := getCallerIdentity(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	callerID := aws.ToString(caller.Account)
+	// Roles of member accounts are in the partition of the caller e.g aws-us-gov or aws-cn
+	partition := "aws"
+	if parts := strings.Split(aws.ToString(caller.Arn), ":"); len(parts) > 1 {
+		partition = parts[1]
+	}
+	roleName := org.RoleName
+	if roleName == "" {
+		roleName = defaultOrganizationRole
+	}
+
+	var accounts []orgAccount
+	seen := make(map[string]bool)
+	for _, m := range members {
+		id := aws.ToString(m.Id)
+		if m.Status != orgTypes.AccountStatusActive || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if len(org.Tags) > 0 {
+			tags, err := getOrgAccountTags(ctx, svc, id)
+			if err != nil {
+				return nil, fmt.Errorf("Could not get tags of account %s: %s", id, err)
+			}
+			if !hasOrgTags(tags, org.Tags) {
+				continue
+			}
+		}
+		account := config.AwsAccount{Name: aws.ToString(m.Name), Regions: org.Regions}
+		if id != callerID {
+			account.RoleArn = fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, id, roleName)
+			account.ExternalID = org.ExternalID
+		}
+		accounts = append(accounts, orgAccount{id: id, AwsAccount: account})
+	}
+	return accounts, nil
+}
+
+// listOUAccounts returns the accounts in an organizational unit and all units within it
+func listOUAccounts(ctx context.Context, svc *organizations.Client, ou string) ([]orgTypes.Account, error) {
+	var accounts []orgTypes.Account
+	p := organizations.NewListAccountsForParentPaginator(svc, &organizations.ListAccountsForParentInput{ParentId: aws.String(ou)}, func(o *organizations.ListAccountsForParentPaginatorOptions) {
+		o.Limit = pageSize(1, 20)
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, page.Accounts...)
+	}
+
+	var children []string
+	ouPages := organizations.NewListOrganizationalUnitsForParentPaginator(svc, &organizations.ListOrganizationalUnitsForParentInput{ParentId: aws.String(ou)}, func(o *organizations.ListOrganizationalUnitsForParentPaginatorOptions) {
+		o.Limit = pageSize(1, 20)
+	})
+	for ouPages.HasMorePages() {
+		page, err := ouPages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range page.OrganizationalUnits {
+			children = append(children, aws.ToString(child.Id))
+		}
+	}
+	for _, child := range children {
+		childAccounts, err := listOUAccounts(ctx, svc, child)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, childAccounts...)
+	}
+	return accounts, nil
+}
+
+func getOrgAccountTags(ctx context.Context, svc *organizations.Client, id string) (map[string]string, error) {
+	tags := make(map[string]string)
+	p := organizations.NewListTagsForResourcePaginator(svc, &organizations.ListTagsForResourceInput{ResourceId: aws.String(id)})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page.Tags {
+			tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+		}
+	}
+	return tags, nil
+}
+
+// hasOrgTags checks if tags has all of wanted. Keys are compared case insensitively as keys in config are
+// lowercased when loaded
+func hasOrgTags(tags, wanted map[string]string) bool {
+	for k, v := range wanted {
+		found := false
+		for tk, tv := range tags {
+			if strings.EqualFold(tk, k) && tv == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
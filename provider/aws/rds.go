@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
@@ -34,109 +35,93 @@ func getrdsInstanceDetails(svc *rds.Client, output *rds.DescribeDBClustersOutput
 }
 
 // GetAllRDSInstances Get all instances
-func GetAllRDSInstances(cfg aws.Config) ([]*resource.Resource, error) {
+func GetAllRDSInstances(ctx context.Context, cfg aws.Config) ([]*resource.Resource, error) {
 	rdsLogger.Debug("Fetching RDS Clusters")
 
 	svc := rds.NewFromConfig(cfg)
 	params := &rds.DescribeDBClustersInput{}
 
-	// Build the request with its input parameters
-	resp, err := svc.DescribeDBClusters(context.TODO(), params)
-
-	if err != nil {
-		return nil, err
-	}
-	instances, err := getrdsInstanceDetails(svc, resp, cfg.Region)
-	if err != nil {
-		return nil, err
+	var instances []*resource.Resource
+	p := rds.NewDescribeDBClustersPaginator(svc, params, func(o *rds.DescribeDBClustersPaginatorOptions) {
+		o.Limit = pageSize(20, 100)
+	})
+	for p.HasMorePages() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pageInstances, err := getrdsInstanceDetails(svc, resp, cfg.Region)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, pageInstances...)
 	}
 	rdsLogger.Debugf("Found %d RDS clusters", len(instances))
 	return instances, nil
 }
 
 // StopRDSInstances Stop Running Instances
-func StopRDSInstances(cfg aws.Config, instances []*resource.Resource) error {
+func StopRDSInstances(ctx context.Context, cfg aws.Config, instances []*resource.Resource, results *resource.Results) error {
 	svc := rds.NewFromConfig(cfg)
-	var instanceIds []rds.StopDBClusterInput
 
 	for _, instance := range instances {
-		if instance.IsActive() {
-			instanceIds = append(instanceIds, rds.StopDBClusterInput{
-				DBClusterIdentifier: &instance.UUID,
-			})
+		if !instance.IsActive() {
+			results.Skipped(instance, fmt.Sprintf("Cluster is %s", instance.Status))
+			continue
 		}
-	}
-
-	if len(instanceIds) <= 0 {
-		return nil
-	}
-
-	for _, instance := range instanceIds {
-		rdsLogger.Debug("Stopping RDS Clusters ", instance.DBClusterIdentifier, " ...")
-		resp, err := svc.StopDBCluster(context.TODO(), &instance)
-		// TODO Attach error to specific instance where the error occurred if possible
+		start := time.Now()
+		rdsLogger.Debug("Stopping RDS Clusters ", instance.UUID, " ...")
+		_, err := svc.StopDBCluster(ctx, &rds.StopDBClusterInput{DBClusterIdentifier: &instance.UUID})
 		if err != nil {
-			fmt.Println(resp, err)
+			rdsLogger.Errorf("Failed to stop RDS Cluster %s: %s", instance.UUID, err)
+			results.Failed(instance, err, start)
+			continue
 		}
-		return err
+		results.Succeeded(instance, start)
 	}
 	return nil
 }
 
 // ResumeRDSInstances Resume Stopped instances
-func ResumeRDSInstances(cfg aws.Config, instances []*resource.Resource) error {
+func ResumeRDSInstances(ctx context.Context, cfg aws.Config, instances []*resource.Resource, results *resource.Results) error {
 	svc := rds.NewFromConfig(cfg)
-	var instanceIds []*rds.StartDBClusterInput
 
 	for _, instance := range instances {
-		if instance.IsStopped() {
-			instanceIds = append(instanceIds, &rds.StartDBClusterInput{
-				DBClusterIdentifier: &instance.UUID,
-			})
+		if !instance.IsStopped() {
+			results.Skipped(instance, fmt.Sprintf("Cluster is %s", instance.Status))
+			continue
 		}
-	}
-
-	if len(instanceIds) <= 0 {
-		return nil
-	}
-
-	for _, instance := range instanceIds {
-		rdsLogger.Debug("Starting RDS Cluster ", instance.DBClusterIdentifier, " ...")
-		resp, err := svc.StartDBCluster(context.TODO(), instance)
-		// TODO Attach error to specific instance where the error occurred if possible
+		start := time.Now()
+		rdsLogger.Debug("Starting RDS Cluster ", instance.UUID, " ...")
+		_, err := svc.StartDBCluster(ctx, &rds.StartDBClusterInput{DBClusterIdentifier: &instance.UUID})
 		if err != nil {
-			fmt.Println(resp, err)
+			rdsLogger.Errorf("Failed to start RDS Cluster %s: %s", instance.UUID, err)
+			results.Failed(instance, err, start)
+			continue
 		}
-		return err
+		results.Succeeded(instance, start)
 	}
 	return nil
 }
 
 // TerminateRDSInstances Shutdown instances
-func TerminateRDSInstances(cfg aws.Config, instances []*resource.Resource) error {
+func TerminateRDSInstances(ctx context.Context, cfg aws.Config, instances []*resource.Resource, results *resource.Results) error {
 	svc := rds.NewFromConfig(cfg)
-	var instanceIds []*rds.DeleteDBClusterInput
 
 	for _, instance := range instances {
-		if instance.IsStopped() || instance.IsActive() {
-			instanceIds = append(instanceIds, &rds.DeleteDBClusterInput{
-				DBClusterIdentifier: &instance.UUID,
-			})
+		if !instance.IsStopped() && !instance.IsActive() {
+			results.Skipped(instance, fmt.Sprintf("Cluster is %s", instance.Status))
+			continue
 		}
-	}
-
-	if len(instanceIds) <= 0 {
-		return nil
-	}
-
-	for _, instance := range instanceIds {
-		rdsLogger.Debug("Terminating RDS Cluster ", instance.DBClusterIdentifier, " ...")
-		resp, err := svc.DeleteDBCluster(context.TODO(), instance)
-		// TODO Attach error to specific instance where the error occurred if possible
+		start := time.Now()
+		rdsLogger.Debug("Terminating RDS Cluster ", instance.UUID, " ...")
+		_, err := svc.DeleteDBCluster(ctx, &rds.DeleteDBClusterInput{DBClusterIdentifier: &instance.UUID})
 		if err != nil {
-			fmt.Println(resp, err)
+			rdsLogger.Errorf("Failed to delete RDS Cluster %s: %s", instance.UUID, err)
+			results.Failed(instance, err, start)
+			continue
 		}
-		return err
+		results.Succeeded(instance, start)
 	}
 	return nil
 }
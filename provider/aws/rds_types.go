@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -29,17 +31,20 @@ func newRDSManager(cfg *config.Config, logPath string) resource.Manager {
 		LongName: rdsLongName,
 		Config:   cfg,
 		Logger:   logger,
-		GetAll: func() ([]*resource.Resource, error) {
-			return GetAllRDSInstances(*cfg.Aws)
+		GetAll: func(ctx context.Context) ([]*resource.Resource, error) {
+			return getAllResources(ctx, GetAllRDSInstances)
+		},
+		GetAllIn: func(ctx context.Context, resources []*resource.Resource) ([]*resource.Resource, error) {
+			return getResourcesIn(ctx, resources, GetAllRDSInstances)
 		},
-		Destroy: func(resources []*resource.Resource) error {
-			return TerminateRDSInstances(*cfg.Aws, resources)
+		Destroy: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, TerminateRDSInstances)
 		},
-		Stop: func(resources []*resource.Resource) error {
-			return StopRDSInstances(*cfg.Aws, resources)
+		Stop: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, StopRDSInstances)
 		},
-		Resume: func(resources []*resource.Resource) error {
-			return ResumeRDSInstances(*cfg.Aws, resources)
+		Resume: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, ResumeRDSInstances)
 		},
 	}
 	return rdsManager
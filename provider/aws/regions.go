@@ -0,0 +1,144 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/resource"
+)
+
+// getRegions returns the regions resources are managed in. `all` is replaced by the regions enabled for the account
+func getRegions(ctx context.Context, cfg aws.Config, regions []string) ([]string, error) {
+	if config.Contains(regions, config.AllRegions) {
+		return getEnabledRegions(ctx, cfg)
+	}
+	var uniqueRegions []string
+	for _, r := range regions {
+		if r = strings.TrimSpace(r); r != "" && !config.Contains(uniqueRegions, r) {
+			uniqueRegions = append(uniqueRegions, r)
+		}
+	}
+	if len(uniqueRegions) == 0 {
+		uniqueRegions = []string{cfg.Region}
+	}
+	return uniqueRegions, nil
+}
+
+// getEnabledRegions returns all regions enabled for the account
+func getEnabledRegions(ctx context.Context, cfg aws.Config) ([]string, error) {
+	svc := ec2.NewFromConfig(cfg)
+	resp, err := svc.DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("Could not get enabled regions: %s", err)
+	}
+	var regions []string
+	for _, r := range resp.Regions {
+		regions = append(regions, *r.RegionName)
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// withRegion returns a copy of cfg for region
+func withRegion(cfg aws.Config, region string) aws.Config {
+	regionCfg := cfg.Copy()
+	if region != "" {
+		regionCfg.Region = region
+	}
+	return regionCfg
+}
+
+// actionFunc is an action on resources in a single region of an account
+type actionFunc func(ctx context.Context, cfg aws.Config, resources []*resource.Resource, results *resource.Results) error
+
+// scopedErrors combines the errors of calls made in several accounts or regions
+type scopedErrors map[string]error
+
+func (errs scopedErrors) Error() string {
+	var msgs []string
+	for scope, err := range errs {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", scope, err))
+	}
+	sort.Strings(msgs)
+	return strings.Join(msgs, "; ")
+}
+
+func (errs scopedErrors) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// getAllInRegions calls getAll concurrently for every region and combines the resources found. Resources found
+// in regions which succeed are returned even if other regions fail
+func getAllInRegions(ctx context.Context, cfg aws.Config, regions []string, getAll func(context.Context, aws.Config) ([]*resource.Resource, error)) ([]*resource.Resource, error) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		resources []*resource.Resource
+		errs      = make(scopedErrors)
+	)
+	for _, region := range regions {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			var res []*resource.Resource
+			err := limiter.Do(ctx, func() (err error) {
+				res, err = getAll(ctx, withRegion(cfg, region))
+				return err
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[region] = err
+			}
+			resources = append(resources, res...)
+		}(region)
+	}
+	wg.Wait()
+	return resources, errs.err()
+}
+
+// actInRegions groups resources by region and calls action concurrently with the resources of each region,
+// so calls are made to the region the resources are in. Resources in regions where action fails are recorded
+// as failed
+func actInRegions(ctx context.Context, cfg aws.Config, resources []*resource.Resource, results *resource.Results, action actionFunc) error {
+	byRegion := make(map[string][]*resource.Resource)
+	for _, r := range resources {
+		byRegion[r.Region] = append(byRegion[r.Region], r)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(scopedErrors)
+	)
+	for region, res := range byRegion {
+		wg.Add(1)
+		go func(region string, res []*resource.Resource) {
+			defer wg.Done()
+			regionCfg := withRegion(cfg, region)
+			start := time.Now()
+			err := limiter.Do(ctx, func() error {
+				return action(ctx, regionCfg, res, results)
+			})
+			results.Complete(res, err, start)
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs[regionCfg.Region] = err
+			}
+		}(region, res)
+	}
+	wg.Wait()
+	return errs.err()
+}
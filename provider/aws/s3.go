@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	s3manager "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
@@ -12,6 +13,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/resource"
+	"github.com/mensaah/reka/rules"
 )
 
 type Object struct {
@@ -19,29 +21,23 @@ type Object struct {
 	VersionId *string
 }
 
-func getS3BucketRegion(cfg aws.Config, bucketName string) (string, error) {
+func getS3BucketRegion(ctx context.Context, cfg aws.Config, bucketName string) (string, error) {
 
-	region, err := s3manager.GetBucketRegion(context.TODO(), s3.NewFromConfig(cfg), bucketName)
+	region, err := s3manager.GetBucketRegion(ctx, s3.NewFromConfig(cfg), bucketName)
 	if err != nil {
-		var notFoundErr *s3Types.NoSuchBucket
-		if errors.As(err, &notFoundErr) {
-			log.Printf("scan failed because the table was not found, %v",
-				notFoundErr.ErrorMessage())
-			return "", fmt.Errorf("unable to find bucket %s's region not found", bucketName)
-		}
 		return "", err
 	}
 	s3Logger.Debugf("Bucket %s is in %s region\n", bucketName, region)
 	return region, err
 }
 
-func getS3BucketTags(svc *s3.Client, bucketName string) (resource.Tags, error) {
+func getS3BucketTags(ctx context.Context, svc *s3.Client, bucketName string) (resource.Tags, error) {
 	s3Logger.Debugf("Fetching S3 Tags for %s", bucketName)
 	input := &s3.GetBucketTaggingInput{
 		Bucket: aws.String(bucketName),
 	}
 
-	result, err := svc.GetBucketTagging(context.TODO(), input)
+	result, err := svc.GetBucketTagging(ctx, input)
 	if err != nil {
 		return resource.Tags{}, err
 	}
@@ -53,24 +49,34 @@ func getS3BucketTags(svc *s3.Client, bucketName string) (resource.Tags, error) {
 }
 
 // returns only s3Bucket IDs of unprotected s3 instances
-func getS3BucketsDetails(svc *s3.Client, cfg aws.Config, output *s3.ListBucketsOutput) ([]*resource.Resource, error) {
+func getS3BucketsDetails(ctx context.Context, svc *s3.Client, cfg aws.Config, output *s3.ListBucketsOutput, regions []string) ([]*resource.Resource, error) {
 	var s3Buckets []*resource.Resource
 	for _, s3Bucket := range output.Buckets {
 		// Get tags
-		tags, err := getS3BucketTags(svc, *s3Bucket.Name)
+		tags, err := getS3BucketTags(ctx, svc, *s3Bucket.Name)
 		if err != nil {
 			s3Logger.Error(err)
 		}
 		tags["creation-date"] = (*s3Bucket.CreationDate).String()
 		// Get region
-		s3Region, err := getS3BucketRegion(cfg, *s3Bucket.Name)
+		s3Region, err := getS3BucketRegion(ctx, cfg, *s3Bucket.Name)
 		if err != nil {
-			s3Logger.Errorf("Could not get region for Bucket %s", *s3Bucket.Name)
-			continue
+			// Buckets deleted since they were listed are gone. Other buckets must not be left out, as buckets
+			// missing from a listing are taken as destroyed
+			var notFound s3manager.BucketNotFound
+			if errors.As(err, &notFound) {
+				s3Logger.Debugf("Bucket %s was deleted while listing buckets", *s3Bucket.Name)
+				continue
+			}
+			return nil, fmt.Errorf("could not get region of bucket %s: %w", *s3Bucket.Name, err)
 		}
 		s3 := NewResource(*s3Bucket.Name, s3Name)
 		s3.Status = resource.Running
 		s3.Region = s3Region
+		// Buckets are listed in all regions
+		if !rules.InRegions(s3, regions) {
+			continue
+		}
 		s3.CreationDate = *s3Bucket.CreationDate
 		s3.Tags = tags
 		s3Buckets = append(s3Buckets, s3)
@@ -81,89 +87,100 @@ func getS3BucketsDetails(svc *s3.Client, cfg aws.Config, output *s3.ListBucketsO
 	return s3Buckets, nil
 }
 
-// GetAllS3Buckets Get all s3Buckets
-func getAllS3Buckets(cfg aws.Config) ([]*resource.Resource, error) {
+// listS3Buckets lists the buckets of an account in regions. Buckets are listed once per account, so the call is
+// limited like calls made in a region
+func listS3Buckets(ctx context.Context, cfg aws.Config, regions []string) (buckets []*resource.Resource, err error) {
+	err = limiter.Do(ctx, func() error {
+		buckets, err = getAllS3Buckets(ctx, cfg, regions)
+		return err
+	})
+	return buckets, err
+}
+
+// GetAllS3Buckets Get all s3Buckets in regions
+func getAllS3Buckets(ctx context.Context, cfg aws.Config, regions []string) ([]*resource.Resource, error) {
 	s3Logger.Debug("Fetching S3 Buckets")
 	svc := s3.NewFromConfig(cfg)
 	params := &s3.ListBucketsInput{}
 
-	// Build the request with its input parameters
-	resp, err := svc.ListBuckets(context.TODO(), params)
+	// ListBuckets is not paginated and returns all buckets in one call
+	resp, err := svc.ListBuckets(ctx, params)
 	if err != nil {
 		return nil, err
 	}
-	buckets, err := getS3BucketsDetails(svc, cfg, resp)
+	buckets, err := getS3BucketsDetails(ctx, svc, cfg, resp, regions)
 	if err != nil {
 		return nil, err
 	}
 	return buckets, nil
 }
 
-func getAllObjects(svc *s3.Client, bucketName *string, isVersioned bool) ([]*Object, error) {
+func getAllObjects(ctx context.Context, svc *s3.Client, bucketName *string, isVersioned bool) ([]*Object, error) {
 	var objects []*Object
 	if !isVersioned {
-		// Set the parameters based on the CLI flag inputs.
 		params := &s3.ListObjectsV2Input{
 			Bucket: bucketName,
 		}
-		// Create the Paginator for the ListObjectsV2 operation.
 		p := s3.NewListObjectsV2Paginator(svc, params, func(o *s3.ListObjectsV2PaginatorOptions) {
-			if v := int32(0); v != 0 {
-				o.Limit = v
-			}
+			o.Limit = pageSize(1, 1000)
 		})
-
-		// Iterate through the S3 object pages, printing each object returned.
-		var i int
-		log.Println("Objects:")
 		for p.HasMorePages() {
-			i++
-			// Next Page takes a new context for each page retrieval. This is where
-			// you could add timeouts or deadlines.
-			page, err := p.NextPage(context.TODO())
+			page, err := p.NextPage(ctx)
 			if err != nil {
-				log.Fatalf("failed to get page %v, %v", i, err)
+				return nil, err
 			}
-
-			// Log the objects found
 			for _, obj := range page.Contents {
-				fmt.Println("Object:", *obj.Key)
+				s3Logger.Debugf("Bucket %s object %s", *bucketName, *obj.Key)
 				objects = append(objects, &Object{Key: obj.Key})
 			}
 		}
 		return objects, nil
 	}
 
-	p, err := svc.ListObjectVersions(context.TODO(), &s3.ListObjectVersionsInput{
-		Bucket: bucketName,
-	})
-	for _, obj := range p.Versions {
-		s3Logger.Debugf("Bucket %s object %s version %s", *bucketName, *obj.Key, *obj.VersionId)
-		objects = append(objects, &Object{
-			Key:       obj.Key,
-			VersionId: obj.VersionId,
-		})
+	params := &s3.ListObjectVersionsInput{
+		Bucket:  bucketName,
+		MaxKeys: pageSize(1, 1000),
 	}
-	for _, obj := range p.DeleteMarkers {
-		s3Logger.Debugf("Bucket %s object %s DeleteMarker %s", *bucketName, *obj.Key, *obj.VersionId)
-		objects = append(objects, &Object{
-			Key:       obj.Key,
-			VersionId: obj.VersionId,
-		})
+	for {
+		p, err := svc.ListObjectVersions(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range p.Versions {
+			s3Logger.Debugf("Bucket %s object %s version %s", *bucketName, *obj.Key, *obj.VersionId)
+			objects = append(objects, &Object{
+				Key:       obj.Key,
+				VersionId: obj.VersionId,
+			})
+		}
+		for _, obj := range p.DeleteMarkers {
+			s3Logger.Debugf("Bucket %s object %s DeleteMarker %s", *bucketName, *obj.Key, *obj.VersionId)
+			objects = append(objects, &Object{
+				Key:       obj.Key,
+				VersionId: obj.VersionId,
+			})
+		}
+		if !p.IsTruncated {
+			return objects, nil
+		}
+		params.KeyMarker = p.NextKeyMarker
+		params.VersionIdMarker = p.NextVersionIdMarker
 	}
-	return objects, err
 }
 
 // Empties a Bucket
-func emptyBucket(svc *s3.Client, bucketName string) error {
-	versioningResult, err := svc.GetBucketVersioning(context.TODO(), &s3.GetBucketVersioningInput{
+func emptyBucket(ctx context.Context, svc *s3.Client, bucketName string) error {
+	versioningResult, err := svc.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{
 		Bucket: &bucketName,
 	})
 	if err != nil {
 		return err
 	}
 
-	objects, err := getAllObjects(svc, &bucketName, versioningResult.Status == s3Types.BucketVersioningStatusEnabled)
+	objects, err := getAllObjects(ctx, svc, &bucketName, versioningResult.Status == s3Types.BucketVersioningStatusEnabled)
+	if err != nil {
+		return err
+	}
 
 	for _, obj := range objects {
 		deleteParams := &s3.DeleteObjectInput{
@@ -171,7 +188,7 @@ func emptyBucket(svc *s3.Client, bucketName string) error {
 			Key:       obj.Key,
 			VersionId: obj.VersionId,
 		}
-		_, err = svc.DeleteObject(context.TODO(), deleteParams)
+		_, err = svc.DeleteObject(ctx, deleteParams)
 		if err != nil {
 			log.Errorf("Failed to delete object %s: %s", *obj.Key, err.Error())
 		}
@@ -180,8 +197,8 @@ func emptyBucket(svc *s3.Client, bucketName string) error {
 }
 
 // Destroys a Single Bucket
-func destroyBucket(svc *s3.Client, bucket *resource.Resource) error {
-	err := emptyBucket(svc, bucket.UUID)
+func destroyBucket(ctx context.Context, svc *s3.Client, bucket *resource.Resource) error {
+	err := emptyBucket(ctx, svc, bucket.UUID)
 	if err != nil {
 		return err
 	}
@@ -190,7 +207,7 @@ func destroyBucket(svc *s3.Client, bucket *resource.Resource) error {
 		Bucket: aws.String(bucket.UUID),
 	}
 
-	_, err = svc.DeleteBucket(context.TODO(), input)
+	_, err = svc.DeleteBucket(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -198,7 +215,7 @@ func destroyBucket(svc *s3.Client, bucket *resource.Resource) error {
 	return nil
 }
 
-func destroyS3Buckets(cfg aws.Config, s3Buckets []*resource.Resource) error {
+func destroyS3Buckets(ctx context.Context, cfg aws.Config, s3Buckets []*resource.Resource, results *resource.Results) error {
 	bucketsPerRegion := make(map[string][]*resource.Resource)
 	delCount := 0
 	if len(s3Buckets) <= 0 {
@@ -215,10 +232,13 @@ func destroyS3Buckets(cfg aws.Config, s3Buckets []*resource.Resource) error {
 			options.Region = region
 		})
 		for _, bucket := range buckets {
-			err := destroyBucket(svc, bucket)
+			start := time.Now()
+			err := destroyBucket(ctx, svc, bucket)
 			if err != nil {
 				s3Logger.Errorf("Failed to delete Bucket %d - Error %s ", bucket.ID, err.Error())
+				results.Failed(bucket, err, start)
 			} else {
+				results.Succeeded(bucket, start)
 				delCount++
 			}
 		}
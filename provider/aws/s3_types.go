@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -25,11 +27,19 @@ func newS3Manager(cfg *config.Config, logPath string) resource.Manager {
 		LongName: s3LongName,
 		Config:   cfg,
 		Logger:   logger,
-		GetAll: func() ([]*resource.Resource, error) {
-			return getAllS3Buckets(*cfg.Aws)
+		GetAll: func(ctx context.Context) ([]*resource.Resource, error) {
+			return getAllInAccounts(ctx, accounts, func(ctx context.Context, a *account) ([]*resource.Resource, error) {
+				return listS3Buckets(ctx, a.cfg, a.regions)
+			})
+		},
+		GetAllIn: func(ctx context.Context, resources []*resource.Resource) ([]*resource.Resource, error) {
+			accts, regions := accountsOf(ctx, resources)
+			return getAllInAccounts(ctx, accts, func(ctx context.Context, a *account) ([]*resource.Resource, error) {
+				return listS3Buckets(ctx, a.cfg, regions[a])
+			})
 		},
-		Destroy: func(resources []*resource.Resource) error {
-			return destroyS3Buckets(*cfg.Aws, resources)
+		Destroy: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actOnResources(ctx, resources, results, destroyS3Buckets)
 		},
 	}
 }
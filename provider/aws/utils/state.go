@@ -65,13 +65,12 @@ func GetEksResourceStatus(s string) resource.Status {
 	return resource.Stopped
 }
 
-func GetResourceFromDesiredState(providerName, resMgr, uid string) (*resource.Resource, error) {
+// GetResourceFromDesiredState returns res as it is in the desired state
+func GetResourceFromDesiredState(providerName, resMgr string, res *resource.Resource) (*resource.Resource, error) {
 	activeState := (state.GetBackend()).GetState()
 
-	for _, w := range activeState.Desired[providerName][resMgr] {
-		if w.UUID == uid {
-			return w, nil
-		}
+	if w := resource.Find(activeState.Desired[providerName][resMgr], res); w != nil {
+		return w, nil
 	}
-	return &resource.Resource{}, fmt.Errorf("%s Resource %s not found in state", resMgr, uid)
+	return &resource.Resource{}, fmt.Errorf("%s Resource %s not found in state", resMgr, res.Key())
 }
@@ -2,24 +2,26 @@ package gcp
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"cloud.google.com/go/storage"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/api/iterator"
 
-	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/provider/types"
 	"github.com/mensaah/reka/resource"
 )
 
-func getAllBuckets(cfg *config.Gcp) ([]*resource.Resource, error) {
+func getAllBuckets(ctx context.Context, project string) ([]*resource.Resource, error) {
 	var buckets []*resource.Resource
 	log.Debug("Fetching Cloud storage buckets")
-	ctx := context.Background()
 	client, err := storage.NewClient(ctx)
 	if err != nil {
 		return []*resource.Resource{}, err
 	}
-	it := client.Buckets(ctx, cfg.ProjectId)
+	it := client.Buckets(ctx, project)
+	it.PageInfo().MaxSize = int(types.PageSize(1, 1000))
 	for {
 		bucketAttrs, err := it.Next()
 		if err == iterator.Done {
@@ -29,6 +31,8 @@ func getAllBuckets(cfg *config.Gcp) ([]*resource.Resource, error) {
 			return []*resource.Resource{}, err
 		}
 		bucket := NewResource(bucketAttrs.Name, cloudStorageName)
+		// Locations are returned in upper case e.g US-EAST1, EU, while regions in config are lower case
+		bucket.Location = strings.ToLower(bucketAttrs.Location)
 		bucket.Status = resource.Running
 		bucket.CreationDate = bucketAttrs.Created
 		bucket.Tags = bucketAttrs.Labels
@@ -43,16 +47,19 @@ func emptyBucket(bucket *resource.Resource) error {
 	return nil
 }
 
-func destroyBuckets(cfg *config.Gcp, buckets []*resource.Resource) error {
-	ctx := context.Background()
+func destroyBuckets(ctx context.Context, project string, buckets []*resource.Resource, results *resource.Results) error {
 	client, err := storage.NewClient(ctx)
 	if err != nil {
 		return err
 	}
 	for _, bucket := range buckets {
+		start := time.Now()
 		if err := client.Bucket(bucket.UUID).Delete(ctx); err != nil {
 			log.Errorf("Failed deleting bucket %s: %s", bucket.UUID, err.Error())
+			results.Failed(bucket, err, start)
+			continue
 		}
+		results.Succeeded(bucket, start)
 	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package gcp
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -25,11 +27,14 @@ func newCloudStorageManager(cfg *config.Config, logPath string) resource.Manager
 		LongName: cloudStorageLongName,
 		Config:   cfg,
 		Logger:   logger,
-		GetAll: func() ([]*resource.Resource, error) {
-			return getAllBuckets(cfg.Gcp)
+		GetAll: func(ctx context.Context) ([]*resource.Resource, error) {
+			return getAllInProjects(ctx, projects, getAllBuckets)
+		},
+		GetAllIn: func(ctx context.Context, resources []*resource.Resource) ([]*resource.Resource, error) {
+			return getAllInProjects(ctx, projectsOf(resources), getAllBuckets)
 		},
-		Destroy: func(resources []*resource.Resource) error {
-			return destroyBuckets(cfg.Gcp, resources)
+		Destroy: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actInProjects(ctx, resources, results, destroyBuckets)
 		},
 	}
 }
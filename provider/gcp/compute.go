@@ -3,113 +3,131 @@ package gcp
 import (
 	"context"
 	"fmt"
+	"path"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	compute "google.golang.org/api/compute/v1"
 
-	"github.com/mensaah/reka/config"
 	"github.com/mensaah/reka/provider/gcp/utils"
+	"github.com/mensaah/reka/provider/types"
 	"github.com/mensaah/reka/resource"
+	"github.com/mensaah/reka/rules"
 )
 
-func getComputeInstancesInZone(svc *compute.InstancesService, projectId string, zone string) ([]*resource.Resource, error) {
-	var computeInstances []*resource.Resource
-	instances, err := svc.List(projectId, zone).Do()
+func newComputeInstance(i *compute.Instance, zone string) *resource.Resource {
+	computeInstance := NewResource(fmt.Sprint(i.Id), computeInstanceName)
+	computeInstance.Zone = zone
+	computeInstance.Region = zoneRegion(zone)
+	computeInstance.Status = utils.GetComputeInstanceStatus(i.Status)
+	creationDate, err := time.Parse(time.RFC3339, i.CreationTimestamp)
 	if err != nil {
-		return []*resource.Resource{}, err
+		computeLogger.Errorf("Could not parse creation time for instance %d, value %s", i.Id, i.CreationTimestamp)
 	}
-
-	for _, i := range instances.Items {
-		computeInstance := NewResource(fmt.Sprint(i.Id), computeInstanceName)
-		computeInstance.Zone = zone
-		computeInstance.Status = utils.GetComputeInstanceStatus(i.Status)
-		creationDate, err := time.Parse(time.RFC3339, i.CreationTimestamp)
-		if err != nil {
-			computeLogger.Error("Could not parse creation time for instance %s, value %s", i.Id, i.CreationTimestamp)
-		}
-		computeInstance.CreationDate = creationDate
-		computeInstance.Tags = i.Labels
-		computeInstances = append(computeInstances, computeInstance)
-	}
-	log.Debugf("Found %d compute Instances in %s zone", len(computeInstances), zone)
-	return computeInstances, nil
+	computeInstance.CreationDate = creationDate
+	computeInstance.Tags = i.Labels
+	return computeInstance
 }
 
-func getAllComputeInstances(cfg *config.Gcp) ([]*resource.Resource, error) {
+// getAllComputeInstances returns the instances of all zones in project using an aggregated list. Only instances
+// in regions are returned when regions is set
+func getAllComputeInstances(ctx context.Context, project string, regions []string) ([]*resource.Resource, error) {
 	var computeInstances []*resource.Resource
 	computeLogger.Debug("Fetching compute Instances")
-	ctx := context.Background()
 	svc, err := compute.NewService(ctx)
 	if err != nil {
 		return []*resource.Resource{}, err
 	}
 	client := compute.NewInstancesService(svc)
-	zone := "us-east1-b"
-	zoneInstances, err := getComputeInstancesInZone(client, cfg.ProjectId, zone)
+	call := client.AggregatedList(project)
+	if size := types.PageSize(1, 500); size > 0 {
+		call.MaxResults(int64(size))
+	}
+	err = call.Pages(ctx, func(page *compute.InstanceAggregatedList) error {
+		// Items are keyed by scope e.g zones/us-east1-b
+		for scope, scoped := range page.Items {
+			zone := path.Base(scope)
+			for _, i := range scoped.Instances {
+				computeInstance := newComputeInstance(i, zone)
+				if rules.InRegions(computeInstance, regions) {
+					computeInstances = append(computeInstances, computeInstance)
+				}
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return []*resource.Resource{}, err
 	}
-	computeInstances = append(computeInstances, zoneInstances...)
+	log.Debugf("Found %d compute Instances in project %s", len(computeInstances), project)
 	return computeInstances, nil
 }
 
 // https://cloud.google.com/compute/docs/reference/latest/instances/stop
-func stopComputeInstances(cfg *config.Gcp, instances []*resource.Resource) error {
+func stopComputeInstances(ctx context.Context, project string, instances []*resource.Resource, results *resource.Results) error {
 	log.Debug("Fetching Cloud storage computeInstance")
-	ctx := context.Background()
 	svc, err := compute.NewService(ctx)
 	if err != nil {
 		computeLogger.Error(err)
+		return err
 	}
 	client := compute.NewInstancesService(svc)
 
 	for _, instance := range instances {
-		// TODO Add operation Waiter to check status of stop operation
+		start := time.Now()
 		// TODO Also allow users to be able to specify the type of stop operation to perform (Suspend/Stop)
 		// https://cloud.google.com/compute/docs/instances/instance-life-cycle#comparison_table
-		_, err := client.Stop(cfg.ProjectId, instance.Zone, instance.UUID).Do()
+		_, err := client.Stop(project, instance.Zone, instance.UUID).Context(ctx).Do()
 		if err != nil {
 			computeLogger.Error(err)
+			results.Failed(instance, err, start)
+			continue
 		}
+		results.Succeeded(instance, start)
 	}
 	return nil
 }
 
-func startComputeInstances(cfg *config.Gcp, instances []*resource.Resource) error {
+func startComputeInstances(ctx context.Context, project string, instances []*resource.Resource, results *resource.Results) error {
 	log.Debug("Fetching Cloud storage computeInstance")
-	ctx := context.Background()
 	svc, err := compute.NewService(ctx)
 	if err != nil {
 		computeLogger.Error(err)
+		return err
 	}
 	client := compute.NewInstancesService(svc)
 
 	for _, instance := range instances {
-		// TODO Add operation Waiter to check status of start operation
-		_, err := client.Start(cfg.ProjectId, instance.Zone, instance.UUID).Do()
+		start := time.Now()
+		_, err := client.Start(project, instance.Zone, instance.UUID).Context(ctx).Do()
 		if err != nil {
 			computeLogger.Error(err)
+			results.Failed(instance, err, start)
+			continue
 		}
+		results.Succeeded(instance, start)
 	}
 	return nil
 }
 
-func destroyComputeInstances(cfg *config.Gcp, instances []*resource.Resource) error {
+func destroyComputeInstances(ctx context.Context, project string, instances []*resource.Resource, results *resource.Results) error {
 	log.Debug("Fetching Cloud storage computeInstance")
-	ctx := context.Background()
 	svc, err := compute.NewService(ctx)
 	if err != nil {
 		computeLogger.Error(err)
+		return err
 	}
 	client := compute.NewInstancesService(svc)
 
 	for _, instance := range instances {
-		// TODO Add operation Waiter to check status of delete operation
-		_, err := client.Delete(cfg.ProjectId, instance.Zone, instance.UUID).Do()
+		start := time.Now()
+		_, err := client.Delete(project, instance.Zone, instance.UUID).Context(ctx).Do()
 		if err != nil {
 			computeLogger.Error(err)
+			results.Failed(instance, err, start)
+			continue
 		}
+		results.Succeeded(instance, start)
 	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package gcp
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -20,22 +22,28 @@ func newComputeInstanceManager(cfg *config.Config, logPath string) resource.Mana
 
 	computeLogger = config.GetLogger(computeInstanceName, logPath)
 
+	getAll := func(ctx context.Context, project string) ([]*resource.Resource, error) {
+		return getAllComputeInstances(ctx, project, cfg.Gcp.Regions)
+	}
 	return resource.Manager{
 		Name:     computeInstanceName,
 		LongName: computeLongName,
 		Config:   cfg,
 		Logger:   computeLogger,
-		GetAll: func() ([]*resource.Resource, error) {
-			return getAllComputeInstances(cfg.Gcp)
+		GetAll: func(ctx context.Context) ([]*resource.Resource, error) {
+			return getAllInProjects(ctx, projects, getAll)
+		},
+		GetAllIn: func(ctx context.Context, resources []*resource.Resource) ([]*resource.Resource, error) {
+			return getAllInProjects(ctx, projectsOf(resources), getAll)
 		},
-		Destroy: func(resources []*resource.Resource) error {
-			return destroyComputeInstances(cfg.Gcp, resources)
+		Destroy: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actInProjects(ctx, resources, results, destroyComputeInstances)
 		},
-		Stop: func(resources []*resource.Resource) error {
-			return stopComputeInstances(cfg.Gcp, resources)
+		Stop: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actInProjects(ctx, resources, results, stopComputeInstances)
 		},
-		Resume: func(resources []*resource.Resource) error {
-			return startComputeInstances(cfg.Gcp, resources)
+		Resume: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actInProjects(ctx, resources, results, startComputeInstances)
 		},
 	}
 }
@@ -1,6 +1,9 @@
 package gcp
 
 import (
+	"context"
+	"strings"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -12,6 +15,9 @@ var (
 	providerName     = "gcp"
 	logger           *log.Entry
 	resourceManagers map[string]*resource.Manager
+	projects         []string
+	// limiter limits the number of projects resources are listed in or acted on at once
+	limiter types.Limiter
 )
 
 func GetName() string {
@@ -24,12 +30,21 @@ func NewResource(id, manager string) *resource.Resource {
 	resource.UUID = id
 	resource.Manager = resourceManagers[manager]
 	resource.ProviderName = providerName
+	resource.Attributes = make(map[string]interface{})
 
 	return &resource
 }
 
+// zoneRegion returns the region of a zone e.g us-east1 for us-east1-b. Regions are returned as they are
+func zoneRegion(location string) string {
+	if i := strings.LastIndex(location, "-"); strings.Count(location, "-") == 2 {
+		return location[:i]
+	}
+	return location
+}
+
 // NewProvider : Creates a New AWS Provider
-func NewProvider() (*types.Provider, error) {
+func NewProvider(ctx context.Context) (*types.Provider, error) {
 
 	gcp := types.Provider{}
 	gcp.Name = providerName
@@ -37,6 +52,13 @@ func NewProvider() (*types.Provider, error) {
 	gcp.SetLogger("logger.log")
 
 	cfg := config.GetConfig()
+	limiter = types.NewLimiter(cfg.Concurrency)
+
+	var err error
+	if projects, err = loadProjects(ctx, cfg.Gcp); err != nil {
+		return nil, err
+	}
+	gcp.Logger.Infof("Managing resources in projects %s", strings.Join(projects, ", "))
 
 	cloudStorageManager := newCloudStorageManager(cfg, gcp.LogPath)
 	computeInstanceManager := newComputeInstanceManager(cfg, gcp.LogPath)
@@ -49,5 +71,6 @@ func NewProvider() (*types.Provider, error) {
 	}
 
 	gcp.Managers = resourceManagers
+	gcp.AccountID = getProjectIDs
 	return &gcp, nil
 }
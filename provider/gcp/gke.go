@@ -3,48 +3,74 @@ package gcp
 import (
 	"context"
 	"fmt"
+	"path"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	compute "google.golang.org/api/compute/v1"
 	gke "google.golang.org/api/container/v1"
 
-	"github.com/mensaah/reka/config"
 	"github.com/mensaah/reka/provider/gcp/utils"
 	"github.com/mensaah/reka/resource"
+	"github.com/mensaah/reka/rules"
 )
 
-func resizeNodePool(svc *gke.ProjectsLocationsClustersNodePoolsService, project string, cluster string, np string, location string, size int64) error {
+func resizeNodePool(ctx context.Context, svc *gke.ProjectsLocationsClustersNodePoolsService, project string, cluster string, np string, location string, size int64) error {
 	name := fmt.Sprintf("projects/%s/locations/%s/clusters/%s/nodePools/%s", project, location, cluster, np)
 	sizeReq := gke.SetNodePoolSizeRequest{
 		ClusterId: cluster,
 		Name:      name,
 		NodeCount: size,
 	}
-	_, err := svc.SetSize(np, &sizeReq).Do()
+	_, err := svc.SetSize(np, &sizeReq).Context(ctx).Do()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func getNodePoolsDetails(svc *gke.ProjectsLocationsClustersService, cluster *gke.Cluster) ([]*resource.Resource, error) {
+func getNodePoolsDetails(ctx context.Context, igm *compute.InstanceGroupManagersService, project string, cluster *gke.Cluster) ([]*resource.Resource, error) {
 	var nodePools []*resource.Resource
 
 	for _, i := range cluster.NodePools {
 		np := NewResource(fmt.Sprint(i.Name), nodePoolName)
 		np.Tags = i.Config.Labels
-		np.Status = resource.Running
+		np.Status = utils.GetComputeInstanceStatus(i.Status)
 		np.Attributes["ActualNodeCount"] = i.InitialNodeCount
+		size, err := getNodePoolSize(ctx, igm, project, i)
+		if err != nil {
+			return nil, fmt.Errorf("could not get size of node pool %s: %w", i.Name, err)
+		}
+		np.Attributes["NodeCount"] = size
 		nodePools = append(nodePools, np)
 	}
 
 	return nodePools, nil
 }
 
-func getGkeClusters(svc *gke.ProjectsLocationsClustersService, projectId string) ([]*resource.Resource, error) {
+// getNodePoolSize returns the number of nodes per zone of a node pool. Node pools are resized per zone, so this is
+// the largest target size of the instance groups of the node pool
+func getNodePoolSize(ctx context.Context, igm *compute.InstanceGroupManagersService, project string, np *gke.NodePool) (int64, error) {
+	var size int64
+	for _, url := range np.InstanceGroupUrls {
+		// URLs are in the form .../zones/<zone>/instanceGroupManagers/<name>
+		zone := path.Base(path.Dir(path.Dir(url)))
+		group, err := igm.Get(project, zone, path.Base(url)).Context(ctx).Do()
+		if err != nil {
+			return 0, err
+		}
+		if group.TargetSize > size {
+			size = group.TargetSize
+		}
+	}
+	return size, nil
+}
+
+func getGkeClusters(ctx context.Context, svc *gke.ProjectsLocationsClustersService, igm *compute.InstanceGroupManagersService, projectId string, regions []string) ([]*resource.Resource, error) {
 	var gkeClusters []*resource.Resource
 	parent := fmt.Sprintf("projects/%s/locations/-", projectId)
-	clusters, err := svc.List(parent).Do()
+	// Clusters are not paginated and all clusters are returned in one call
+	clusters, err := svc.List(parent).Context(ctx).Do()
 	if err != nil {
 		return []*resource.Resource{}, err
 	}
@@ -53,10 +79,14 @@ func getGkeClusters(svc *gke.ProjectsLocationsClustersService, projectId string)
 		cluster := NewResource(fmt.Sprint(i.Name), gkeName)
 
 		cluster.Location = i.Location
+		cluster.Region = zoneRegion(i.Location)
+		if !rules.InRegions(cluster, regions) {
+			continue
+		}
 		cluster.Status = utils.GetComputeInstanceStatus(i.Status)
 		creationDate, err := time.Parse(time.RFC3339, i.CreateTime)
 		if err != nil {
-			gkeLogger.Error("Could not parse creation time for cluster %s, value %s", i.Name, i.CreateTime)
+			gkeLogger.Errorf("Could not parse creation time for cluster %s, value %s", i.Name, i.CreateTime)
 		}
 		cluster.CreationDate = creationDate
 		cluster.Tags = i.ResourceLabels
@@ -64,10 +94,10 @@ func getGkeClusters(svc *gke.ProjectsLocationsClustersService, projectId string)
 			// Add Node Pool Data to Cluster
 			cluster.SubResources = make(map[string][]*resource.Resource)
 
-			nodePools, err := getNodePoolsDetails(svc, i)
+			// Clusters missing from a listing are taken as destroyed, so the listing fails instead of skipping them
+			nodePools, err := getNodePoolsDetails(ctx, igm, projectId, i)
 			if err != nil {
-				gkeLogger.Error(err)
-				continue
+				return []*resource.Resource{}, fmt.Errorf("cluster %s: %w", i.Name, err)
 			}
 			cluster.SubResources[nodePoolName] = nodePools
 		}
@@ -78,16 +108,19 @@ func getGkeClusters(svc *gke.ProjectsLocationsClustersService, projectId string)
 	return gkeClusters, nil
 }
 
-func getAllGkeClusters(cfg *config.Gcp) ([]*resource.Resource, error) {
+func getAllGkeClusters(ctx context.Context, project string, regions []string) ([]*resource.Resource, error) {
 	var gkeClusters []*resource.Resource
 	gkeLogger.Debug("Fetching GKE Clusters")
-	ctx := context.Background()
 	svc, err := gke.NewService(ctx)
 	if err != nil {
 		return []*resource.Resource{}, err
 	}
+	computeSvc, err := compute.NewService(ctx)
+	if err != nil {
+		return []*resource.Resource{}, err
+	}
 	client := gke.NewProjectsLocationsClustersService(svc)
-	clusters, err := getGkeClusters(client, cfg.ProjectId)
+	clusters, err := getGkeClusters(ctx, client, compute.NewInstanceGroupManagersService(computeSvc), project, regions)
 	if err != nil {
 		return []*resource.Resource{}, err
 	}
@@ -96,113 +129,171 @@ func getAllGkeClusters(cfg *config.Gcp) ([]*resource.Resource, error) {
 }
 
 // https://cloud.google.com/gke/docs/reference/latest/clusters/stop
-func stopGkeClusters(cfg *config.Gcp, clusters []*resource.Resource) error {
+func stopGkeClusters(ctx context.Context, project string, clusters []*resource.Resource, results *resource.Results) error {
 	log.Debug("Fetching  cluster")
 	// TODO Allow configurable options for setting stop cluster size
 	stopSize := int64(0)
 
-	var selectedClusters []*resource.Resource
-	for _, cluster := range clusters {
-		if cluster.IsActive() {
-			selectedClusters = append(selectedClusters, cluster)
-		}
-	}
-
-	if len(clusters) <= 0 {
+	selectedClusters := activeClusters(clusters, results)
+	if len(selectedClusters) <= 0 {
 		return nil
 	}
 
-	ctx := context.Background()
 	svc, err := gke.NewService(ctx)
 	if err != nil {
 		gkeLogger.Error(err)
+		return err
 	}
 	client := gke.NewProjectsLocationsClustersNodePoolsService(svc)
 
-	for _, cluster := range clusters {
-		// TODO Add operation Waiter to check status of stop operation
+	for _, cluster := range selectedClusters {
+		start := time.Now()
 		// TODO [GKE] Also allow users to be able to specify the type of stop operation to perform (Suspend/Stop)
 		// https://cloud.google.com/gke/docs/clusters/cluster-life-cycle#comparison_table
+		var err error
 		for _, np := range cluster.SubResources[nodePoolName] {
-			err := resizeNodePool(client, cfg.ProjectId, cluster.UUID, np.UUID, cluster.Location, stopSize)
-			if err != nil {
-				gkeLogger.Error(err)
+			if npErr := resizeNodePool(ctx, client, project, cluster.UUID, np.UUID, cluster.Location, stopSize); npErr != nil {
+				gkeLogger.Error(npErr)
+				err = fmt.Errorf("Node pool %s: %s", np.UUID, npErr)
 			}
 		}
+		if err != nil {
+			results.Failed(cluster, err, start)
+			continue
+		}
+		results.Succeeded(cluster, start)
 	}
 	return nil
 }
 
-func startGkeClusters(cfg *config.Gcp, clusters []*resource.Resource) error {
+func startGkeClusters(ctx context.Context, project string, clusters []*resource.Resource, results *resource.Results) error {
 	log.Debug("Fetching cluster")
 	// TODO Allow configurable options for setting stop cluster size
 
-	var selectedClusters []*resource.Resource
-	for _, cluster := range clusters {
-		if cluster.IsActive() {
-			selectedClusters = append(selectedClusters, cluster)
-		}
-	}
-
+	selectedClusters := activeClusters(clusters, results)
 	if len(selectedClusters) <= 0 {
 		return nil
 	}
 
-	ctx := context.Background()
 	svc, err := gke.NewService(ctx)
 	if err != nil {
 		gkeLogger.Error(err)
+		return err
 	}
 	client := gke.NewProjectsLocationsClustersNodePoolsService(svc)
 
 	for _, cluster := range selectedClusters {
-		desired, err := utils.GetResourceFromDesiredState(providerName, gkeName, cluster.UUID)
+		start := time.Now()
+		desired, err := utils.GetResourceFromDesiredState(providerName, gkeName, cluster)
 		if err != nil {
 			gkeLogger.Error(err)
+			results.Failed(cluster, err, start)
 			continue
 		}
-		// TODO Add operation Waiter to check status of stop operation
 		// TODO [GKE] Also allow users to be able to specify the type of stop operation to perform (Suspend/Stop)
 		// https://cloud.google.com/gke/docs/clusters/cluster-life-cycle#comparison_table
 		for _, np := range desired.SubResources[nodePoolName] {
-			desiredSize, _ := np.Attributes["ActualNodeCount"].(int64)
-			err := resizeNodePool(client, cfg.ProjectId, cluster.UUID, np.UUID, cluster.Region, desiredSize)
-			if err != nil {
-				gkeLogger.Error(err)
+			desiredSize, ok := np.IntAttribute("ActualNodeCount")
+			if !ok {
+				err = fmt.Errorf("Node pool %s has no node count in state", np.UUID)
+				continue
+			}
+			if npErr := resizeNodePool(ctx, client, project, cluster.UUID, np.UUID, cluster.Location, desiredSize); npErr != nil {
+				gkeLogger.Error(npErr)
+				err = fmt.Errorf("Node pool %s: %s", np.UUID, npErr)
 			}
 		}
+		if err != nil {
+			results.Failed(cluster, err, start)
+			continue
+		}
+		results.Succeeded(cluster, start)
 	}
 	return nil
 }
 
-func destroyGkeClusters(cfg *config.Gcp, clusters []*resource.Resource) error {
-	var selectedClusters []*resource.Resource
-	for _, cluster := range clusters {
-		if cluster.IsActive() {
-			selectedClusters = append(selectedClusters, cluster)
-		}
-	}
-
+func destroyGkeClusters(ctx context.Context, project string, clusters []*resource.Resource, results *resource.Results) error {
+	selectedClusters := activeClusters(clusters, results)
 	if len(selectedClusters) <= 0 {
 		return nil
 	}
 
 	log.Debugf("Destroying %d clusters", len(selectedClusters))
 
-	ctx := context.Background()
 	svc, err := gke.NewService(ctx)
 	if err != nil {
 		gkeLogger.Error(err)
+		return err
 	}
 	client := gke.NewProjectsLocationsClustersService(svc)
 
 	for _, cluster := range selectedClusters {
-		// TODO Add operation Waiter to check status of delete operation
-		name := fmt.Sprintf("projects/%s/locations/%s/clusters/%s", cfg.ProjectId, cluster.Location, cluster.UUID)
-		_, err := client.Delete(name).Do()
+		start := time.Now()
+		name := fmt.Sprintf("projects/%s/locations/%s/clusters/%s", project, cluster.Location, cluster.UUID)
+		_, err := client.Delete(name).Context(ctx).Do()
 		if err != nil {
 			gkeLogger.Error(err)
+			results.Failed(cluster, err, start)
+			continue
 		}
+		results.Succeeded(cluster, start)
 	}
 	return nil
 }
+
+// activeClusters returns the active clusters and records the others as skipped. Clusters stay active when their
+// node pools are resized to zero
+func activeClusters(clusters []*resource.Resource, results *resource.Results) []*resource.Resource {
+	var selectedClusters []*resource.Resource
+	for _, cluster := range clusters {
+		if !cluster.IsActive() {
+			results.Skipped(cluster, fmt.Sprintf("Cluster is %s", cluster.Status))
+			continue
+		}
+		selectedClusters = append(selectedClusters, cluster)
+	}
+	return selectedClusters
+}
+
+// gkeClusterConverged checks if a cluster reached expected. Clusters stay running when they are stopped or resumed,
+// so their node pools are checked instead. Stopped node pools have no nodes, and resumed node pools have the node
+// count stored in the desired state
+func gkeClusterConverged(cluster *resource.Resource, expected resource.Status) bool {
+	if expected != resource.Stopped && expected != resource.Running {
+		return cluster.Status == expected
+	}
+	if !cluster.IsActive() {
+		return false
+	}
+	var desired []*resource.Resource
+	if expected == resource.Running {
+		d, err := utils.GetResourceFromDesiredState(providerName, gkeName, cluster)
+		if err != nil {
+			gkeLogger.Error(err)
+			return false
+		}
+		desired = d.SubResources[nodePoolName]
+	}
+	for _, np := range cluster.SubResources[nodePoolName] {
+		if !np.IsActive() {
+			return false
+		}
+		size, ok := np.IntAttribute("NodeCount")
+		if !ok {
+			return false
+		}
+		want := int64(0)
+		if expected == resource.Running {
+			d := resource.Find(desired, np)
+			if d == nil {
+				// Node pools created since the cluster was stopped are not resized
+				continue
+			}
+			want, _ = d.IntAttribute("ActualNodeCount")
+		}
+		if size != want {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,8 @@
 package gcp
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mensaah/reka/config"
@@ -21,22 +23,29 @@ func newGkeManager(cfg *config.Config, logPath string) resource.Manager {
 
 	gkeLogger = config.GetLogger(gkeName, logPath)
 
+	getAll := func(ctx context.Context, project string) ([]*resource.Resource, error) {
+		return getAllGkeClusters(ctx, project, cfg.Gcp.Regions)
+	}
 	return resource.Manager{
 		Name:     gkeName,
 		LongName: gkeLongName,
 		Config:   cfg,
 		Logger:   gkeLogger,
-		GetAll: func() ([]*resource.Resource, error) {
-			return getAllGkeClusters(cfg.Gcp)
+		GetAll: func(ctx context.Context) ([]*resource.Resource, error) {
+			return getAllInProjects(ctx, projects, getAll)
+		},
+		GetAllIn: func(ctx context.Context, resources []*resource.Resource) ([]*resource.Resource, error) {
+			return getAllInProjects(ctx, projectsOf(resources), getAll)
 		},
-		Destroy: func(resources []*resource.Resource) error {
-			return destroyGkeClusters(cfg.Gcp, resources)
+		Destroy: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actInProjects(ctx, resources, results, destroyGkeClusters)
 		},
-		Stop: func(resources []*resource.Resource) error {
-			return stopGkeClusters(cfg.Gcp, resources)
+		Stop: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actInProjects(ctx, resources, results, stopGkeClusters)
 		},
-		Resume: func(resources []*resource.Resource) error {
-			return startGkeClusters(cfg.Gcp, resources)
+		Resume: func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error {
+			return actInProjects(ctx, resources, results, startGkeClusters)
 		},
+		Converged: gkeClusterConverged,
 	}
 }
@@ -0,0 +1,224 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	crmv1 "google.golang.org/api/cloudresourcemanager/v1"
+	crmv2 "google.golang.org/api/cloudresourcemanager/v2"
+
+	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/resource"
+)
+
+// loadProjects returns the IDs of the projects in config and of the active projects under the folders and
+// organizations in config
+func loadProjects(ctx context.Context, cfg *config.Gcp) ([]string, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("No GCP projects configured")
+	}
+	var projectIds []string
+	add := func(ids ...string) {
+		for _, id := range ids {
+			if id = strings.TrimSpace(id); id != "" && !config.Contains(projectIds, id) {
+				projectIds = append(projectIds, id)
+			}
+		}
+	}
+	add(cfg.ProjectId)
+	add(cfg.ProjectIds...)
+
+	if len(cfg.Folders) > 0 || len(cfg.Organizations) > 0 {
+		projectsSvc, err := crmv1.NewService(ctx)
+		if err != nil {
+			return nil, err
+		}
+		foldersSvc, err := crmv2.NewService(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var parents []string
+		for _, f := range cfg.Folders {
+			parents = append(parents, "folders/"+strings.TrimPrefix(f, "folders/"))
+		}
+		for _, o := range cfg.Organizations {
+			parents = append(parents, "organizations/"+strings.TrimPrefix(o, "organizations/"))
+		}
+		for _, parent := range parents {
+			ids, err := listProjectsUnder(ctx, projectsSvc, foldersSvc, parent)
+			if err != nil {
+				return nil, fmt.Errorf("Could not list projects of %s: %s", parent, err)
+			}
+			add(ids...)
+		}
+	}
+
+	if len(projectIds) == 0 {
+		return nil, fmt.Errorf("No GCP projects configured")
+	}
+	return projectIds, nil
+}
+
+// listProjectsUnder returns the active projects in parent e.g folders/123 and in all folders within it
+func listProjectsUnder(ctx context.Context, projectsSvc *crmv1.Service, foldersSvc *crmv2.Service, parent string) ([]string, error) {
+	parts := strings.SplitN(parent, "/", 2)
+	parentType := strings.TrimSuffix(parts[0], "s")
+	filter := fmt.Sprintf("parent.type:%s parent.id:%s lifecycleState:ACTIVE", parentType, parts[1])
+
+	var projectIds []string
+	err := projectsSvc.Projects.List().Filter(filter).Pages(ctx, func(resp *crmv1.ListProjectsResponse) error {
+		for _, p := range resp.Projects {
+			projectIds = append(projectIds, p.ProjectId)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var folders []string
+	err = foldersSvc.Folders.List().Parent(parent).Pages(ctx, func(resp *crmv2.ListFoldersResponse) error {
+		for _, f := range resp.Folders {
+			if f.LifecycleState == "ACTIVE" {
+				folders = append(folders, f.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range folders {
+		ids, err := listProjectsUnder(ctx, projectsSvc, foldersSvc, f)
+		if err != nil {
+			return nil, err
+		}
+		projectIds = append(projectIds, ids...)
+	}
+	return projectIds, nil
+}
+
+// getProjectIDs returns the IDs of all projects joined by commas
+func getProjectIDs(ctx context.Context) (string, error) {
+	ids := append([]string{}, projects...)
+	sort.Strings(ids)
+	return strings.Join(ids, ","), nil
+}
+
+// getProject returns the project of a resource. Resources tracked before projects were recorded have no project
+// and belong to the only project if a single project is configured
+func getProject(id string) (string, bool) {
+	if id == "" && len(projects) == 1 {
+		return projects[0], true
+	}
+	return id, config.Contains(projects, id)
+}
+
+// projectsOf returns the configured projects resources belong to
+func projectsOf(resources []*resource.Resource) []string {
+	var ids []string
+	for _, r := range resources {
+		if project, ok := getProject(r.AccountID); ok && !config.Contains(ids, project) {
+			ids = append(ids, project)
+		}
+	}
+	return ids
+}
+
+// projectErrors combines the errors of calls made in several projects
+type projectErrors map[string]error
+
+func (errs projectErrors) Error() string {
+	var msgs []string
+	for project, err := range errs {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", project, err))
+	}
+	sort.Strings(msgs)
+	return strings.Join(msgs, "; ")
+}
+
+func (errs projectErrors) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// getAllInProjects calls getAll concurrently for every project of ids and stamps the resources found with their
+// project. Resources found in projects which succeed are returned even if other projects fail
+func getAllInProjects(ctx context.Context, ids []string, getAll func(ctx context.Context, project string) ([]*resource.Resource, error)) ([]*resource.Resource, error) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		resources []*resource.Resource
+		errs      = make(projectErrors)
+	)
+	for _, p := range ids {
+		wg.Add(1)
+		go func(project string) {
+			defer wg.Done()
+			var res []*resource.Resource
+			err := limiter.Do(ctx, func() (err error) {
+				res, err = getAll(ctx, project)
+				return err
+			})
+			for _, r := range res {
+				r.AccountID = project
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[project] = err
+			}
+			resources = append(resources, res...)
+		}(p)
+	}
+	wg.Wait()
+	return resources, errs.err()
+}
+
+// actInProjects groups resources by project and calls action concurrently with the resources of each project.
+// Resources without a result once action returns are recorded with the outcome of the call
+func actInProjects(ctx context.Context, resources []*resource.Resource, results *resource.Results, action func(ctx context.Context, project string, resources []*resource.Resource, results *resource.Results) error) error {
+	byProject := make(map[string][]*resource.Resource)
+	for _, r := range resources {
+		byProject[r.AccountID] = append(byProject[r.AccountID], r)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(projectErrors)
+	)
+	for id, res := range byProject {
+		project, ok := getProject(id)
+		if !ok {
+			err := fmt.Errorf("Project is not configured")
+			results.Complete(res, err, time.Now())
+			mu.Lock()
+			errs[id] = err
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(project string, res []*resource.Resource) {
+			defer wg.Done()
+			start := time.Now()
+			err := limiter.Do(ctx, func() error {
+				return action(ctx, project, res, results)
+			})
+			results.Complete(res, err, start)
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs[project] = err
+			}
+		}(project, res)
+	}
+	wg.Wait()
+	return errs.err()
+}
@@ -10,7 +10,7 @@ import (
 // GetResourceStatus Get the current status of Resource: Pending, Running, ... Stopped
 func GetComputeInstanceStatus(s string) resource.Status {
 	switch s {
-	case "PROVISIONING", "REPARING", "STAGING":
+	case "PROVISIONING", "REPARING", "STAGING", "RECONCILING":
 		return resource.Pending
 	case "RUNNING":
 		return resource.Running
@@ -27,13 +27,12 @@ func GetComputeInstanceStatus(s string) resource.Status {
 	}
 }
 
-func GetResourceFromDesiredState(providerName, resMgr, uid string) (*resource.Resource, error) {
+// GetResourceFromDesiredState returns res as it is in the desired state
+func GetResourceFromDesiredState(providerName, resMgr string, res *resource.Resource) (*resource.Resource, error) {
 	activeState := (state.GetBackend()).GetState()
 
-	for _, w := range activeState.Desired[providerName][resMgr] {
-		if w.UUID == uid {
-			return w, nil
-		}
+	if w := resource.Find(activeState.Desired[providerName][resMgr], res); w != nil {
+		return w, nil
 	}
-	return &resource.Resource{}, fmt.Errorf("%s Resource %s not found in state", resMgr, uid)
+	return &resource.Resource{}, fmt.Errorf("%s Resource %s not found in state", resMgr, res.Key())
 }
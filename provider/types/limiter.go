@@ -0,0 +1,31 @@
+package types
+
+import "context"
+
+// Limiter limits the number of functions running at once
+type Limiter chan struct{}
+
+// NewLimiter returns a Limiter which runs up to n functions at once. There is no limit when n is 0
+func NewLimiter(n int) Limiter {
+	if n <= 0 {
+		return nil
+	}
+	return make(Limiter, n)
+}
+
+// Do runs fn once fewer than the limit of functions are running and returns its error. fn is not run if ctx is
+// done before then
+func (l Limiter) Do(ctx context.Context, fn func() error) error {
+	if l != nil {
+		select {
+		case l <- struct{}{}:
+			defer func() { <-l }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fn()
+}
@@ -1,9 +1,12 @@
 package types
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -16,12 +19,27 @@ import (
 // resources managed by the manager
 type Resources map[string][]*resource.Resource
 
+// Count returns the number of resources of all managers
+func (r Resources) Count() int {
+	count := 0
+	for _, res := range r {
+		count += len(res)
+	}
+	return count
+}
+
 type SafeResources struct {
 	mu  sync.Mutex
 	v   Resources
 	err map[string]error
 }
 
+// NukeReport : The outcome of nuking resources of a provider
+type NukeReport struct {
+	Results  []*resource.Result
+	Excluded Resources
+}
+
 // Provider : Provider definition
 // Implements all logic for controlling Resource Managers
 type Provider struct {
@@ -29,6 +47,12 @@ type Provider struct {
 	Logger   *log.Entry
 	LogPath  string
 	Managers map[string]*resource.Manager // [mgrName: Manager]
+
+	// AccountID returns the ID of the account (AWS) or project (GCP) the provider operates on
+	AccountID func(ctx context.Context) (string, error)
+	// Discover reloads the accounts or projects the provider operates on, so accounts created since the provider was
+	// created are covered. It is optional and must not be called while resources are listed or acted on
+	Discover func(ctx context.Context) error
 }
 
 // SetLogger : Sets Logger properties for Provider
@@ -49,8 +73,32 @@ func (p *Provider) SetLogger(id string) {
 	p.Logger = config.GetLogger(p.Name, logFile)
 }
 
+// PageSize returns the configured page size limited to the range an API allows. The API's default is used when
+// it is not set
+func PageSize(min, max int32) int32 {
+	size := config.GetConfig().PageSize
+	switch {
+	case size == 0:
+		return 0
+	case size < min:
+		return min
+	case size > max:
+		return max
+	}
+	return size
+}
+
+// withOperationTimeout returns a context for a single call to a manager which is cancelled after the configured
+// operationTimeout
+func withOperationTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := config.GetConfig().OperationTimeout; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 // GetAllResources : Returns all resources which reka can find
-func (p *Provider) GetAllResources() Resources {
+func (p *Provider) GetAllResources(ctx context.Context) Resources {
 	p.Logger.Info("Fetching All Resources")
 	var wg sync.WaitGroup
 	resources := SafeResources{v: make(Resources)}
@@ -58,8 +106,10 @@ func (p *Provider) GetAllResources() Resources {
 		wg.Add(1)
 		go func(res *SafeResources, resMgr *resource.Manager) {
 			defer wg.Done()
+			ctx, cancel := withOperationTimeout(ctx)
+			defer cancel()
 
-			resMgrResources, err := resMgr.GetAll()
+			resMgrResources, err := resMgr.GetAll(ctx)
 			if err != nil {
 				p.Logger.Error(err)
 			}
@@ -80,11 +130,8 @@ func (p *Provider) GetDestroyableResources(resources Resources) Resources {
 	for mgrName, resList := range resources {
 		var destroyableResList []*resource.Resource
 		for _, r := range resList {
-			// Returns the first Matching Rule Action for a resource
-			for _, rule := range rules.GetRules() {
-				if action := rule.CheckResource(r); action == rules.Destroy {
-					destroyableResList = append(destroyableResList, r)
-				}
+			if rules.GetResourceAction(r) == rules.Destroy {
+				destroyableResList = append(destroyableResList, r)
 			}
 		}
 		count += len(destroyableResList)
@@ -102,10 +149,8 @@ func (p *Provider) GetStoppableResources(resources Resources) Resources {
 	for mgrName, resList := range resources {
 		var stoppableResList []*resource.Resource
 		for _, r := range resList {
-			for _, rule := range rules.GetRules() {
-				if action := rule.CheckResource(r); r.IsActive() && action == rules.Stop {
-					stoppableResList = append(stoppableResList, r)
-				}
+			if r.IsActive() && rules.GetResourceAction(r) == rules.Stop {
+				stoppableResList = append(stoppableResList, r)
 			}
 		}
 		if p.getManager(mgrName).Stop != nil && p.getManager(mgrName).Resume != nil {
@@ -125,10 +170,8 @@ func (p *Provider) GetResumableResources(resources Resources) Resources {
 	for mgrName, resList := range resources {
 		var resumableResList []*resource.Resource
 		for _, r := range resList {
-			for _, rule := range rules.GetRules() {
-				if action := rule.CheckResource(r); r.IsStopped() && action == rules.Resume {
-					resumableResList = append(resumableResList, r)
-				}
+			if r.IsStopped() && rules.GetResourceAction(r) == rules.Resume {
+				resumableResList = append(resumableResList, r)
 			}
 		}
 		if p.getManager(mgrName).Stop != nil && p.getManager(mgrName).Resume != nil {
@@ -155,78 +198,72 @@ func (p *Provider) GetUnusedResources(resources Resources) Resources {
 	return unusedResources
 }
 
-// DestroyResources : Return the resources which can be destroyed
-func (p *Provider) DestroyResources(resources Resources) map[string]error {
-	errs := make(map[string]error)
-	p.Logger.Info("Destroying Resources...")
-	var wg sync.WaitGroup
+// actionFunc is an action of a resource manager
+type actionFunc func(ctx context.Context, resources []*resource.Resource, results *resource.Results) error
 
+// act calls the action of each manager concurrently with its resources and returns the result for every resource.
+// Resources of managers without the action are skipped. When wait is enabled, resources the action succeeded on
+// are polled until they reach status
+func (p *Provider) act(ctx context.Context, resources Resources, name string, status resource.Status, action func(*resource.Manager) actionFunc) []*resource.Result {
+	var wg sync.WaitGroup
+	results := resource.NewResults()
 	for mgrName, res := range resources {
-		if len(res) > 0 {
-			wg.Add(1)
-			go func(mgrName string, res []*resource.Resource) {
-				defer wg.Done()
-				mgr := p.getManager(mgrName)
-				p.Logger.Debugf("Destroying %s ", mgrName)
-				if err := mgr.Destroy(res); err != nil {
-					errs[mgrName] = err
-				}
-			}(mgrName, res)
+		if len(res) == 0 {
+			continue
+		}
+		mgr := p.getManager(mgrName)
+		if mgr == nil || action(mgr) == nil {
+			for _, r := range res {
+				results.Skipped(r, fmt.Sprintf("%s is not supported by %s", name, mgrName))
+			}
+			continue
 		}
+		wg.Add(1)
+		go func(mgr *resource.Manager, res []*resource.Resource) {
+			defer wg.Done()
+			actCtx, cancel := withOperationTimeout(ctx)
+			defer cancel()
+			p.Logger.Debugf("%s %d %s resources", name, len(res), mgr.Name)
+			start := time.Now()
+			err := action(mgr)(actCtx, res, results)
+			if err != nil {
+				p.Logger.Errorf("%s: %s", mgr.Name, err)
+			}
+			results.Complete(res, err, start)
+			if config.GetConfig().Wait {
+				p.waitFor(ctx, mgr, res, status, results)
+			}
+		}(mgr, res)
 	}
 	wg.Wait()
-	return errs
+	return results.List()
 }
 
-// StopResources : Return the resources which can be destroyed
-func (p *Provider) StopResources(resources Resources) map[string]error {
-	errs := make(map[string]error)
-	p.Logger.Info("Stopping Resources...")
-	var wg sync.WaitGroup
-
-	for mgrName, res := range resources {
-		if len(res) > 0 {
-			wg.Add(1)
-
-			go func(mgrName string, res []*resource.Resource) {
-				defer wg.Done()
-				mgr := p.getManager(mgrName)
-				if mgr.Stop != nil {
-					p.Logger.Debugf("Stopping %d %s Resources", len(res), mgrName)
-					if err := mgr.Stop(res); err != nil {
-						errs[mgrName] = err
-					}
-				}
-			}(mgrName, res)
+// DestroyResources : Destroys resources and returns the result for each resource
+func (p *Provider) DestroyResources(ctx context.Context, resources Resources) []*resource.Result {
+	if rules.IsFrozen() {
+		results := resource.NewResults()
+		for _, res := range resources {
+			for _, r := range res {
+				results.Skipped(r, "Change freeze in effect")
+			}
 		}
+		return results.List()
 	}
-	wg.Wait()
-	return errs
+	p.Logger.Info("Destroying Resources...")
+	return p.act(ctx, resources, "Destroy", resource.Destroyed, func(mgr *resource.Manager) actionFunc { return mgr.Destroy })
 }
 
-// ResumeResources : Return the resources which can be destroyed
-func (p *Provider) ResumeResources(resources Resources) map[string]error {
-	var wg sync.WaitGroup
-	errs := make(map[string]error)
-	p.Logger.Info("Resuming Resources...")
+// StopResources : Stops resources and returns the result for each resource
+func (p *Provider) StopResources(ctx context.Context, resources Resources) []*resource.Result {
+	p.Logger.Info("Stopping Resources...")
+	return p.act(ctx, resources, "Stop", resource.Stopped, func(mgr *resource.Manager) actionFunc { return mgr.Stop })
+}
 
-	for mgrName, res := range resources {
-		if len(res) > 0 {
-			wg.Add(1)
-			go func(mgrName string, res []*resource.Resource) {
-				defer wg.Done()
-				mgr := p.getManager(mgrName)
-				if mgr.Resume != nil {
-					p.Logger.Debugf("Resuming %d %s Resources", len(res), mgrName)
-					if err := mgr.Resume(res); err != nil {
-						errs[mgrName] = err
-					}
-				}
-			}(mgrName, res)
-		}
-	}
-	wg.Wait()
-	return errs
+// ResumeResources : Resumes resources and returns the result for each resource
+func (p *Provider) ResumeResources(ctx context.Context, resources Resources) []*resource.Result {
+	p.Logger.Info("Resuming Resources...")
+	return p.act(ctx, resources, "Resume", resource.Running, func(mgr *resource.Manager) actionFunc { return mgr.Resume })
 }
 
 func (p *Provider) getManager(name string) *resource.Manager {
@@ -234,9 +271,25 @@ func (p *Provider) getManager(name string) *resource.Manager {
 }
 
 // Nuke : POOF !!!
-// destroys everything tracked by reka
-func (p *Provider) Nuke() (string, error) {
-	return "", nil
+// destroys all resources passed regardless of rules. Resources matching exclude rules are not destroyed
+func (p *Provider) Nuke(ctx context.Context, resources Resources) NukeReport {
+	p.Logger.Warn("Nuking Resources...")
+	report := NukeReport{
+		Excluded: make(Resources),
+	}
+	targets := make(Resources)
+	for mgrName, resList := range resources {
+		for _, r := range resList {
+			if rules.IsExcluded(r) {
+				report.Excluded[mgrName] = append(report.Excluded[mgrName], r)
+				continue
+			}
+			targets[mgrName] = append(targets[mgrName], r)
+		}
+	}
+
+	report.Results = p.DestroyResources(ctx, targets)
+	return report
 }
 
 // GetResourceNames Get a array of resource names
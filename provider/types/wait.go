@@ -0,0 +1,91 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/resource"
+)
+
+// waitFor polls mgr until the resources an action succeeded on reach status. Only the accounts and regions of the
+// resources still pending are polled when mgr supports it. Resources which do not reach status within the configured
+// waitTimeout are recorded as failed
+func (p *Provider) waitFor(ctx context.Context, mgr *resource.Manager, resources []*resource.Resource, status resource.Status, results *resource.Results) {
+	cfg := config.GetConfig()
+	// Start of the action on each resource so waiting is included in the duration of its result
+	pending := make(map[*resource.Resource]time.Time)
+	for _, r := range resources {
+		if result, ok := results.Get(r); ok && result.Outcome == resource.Succeeded {
+			pending[r] = time.Now().Add(-result.Duration)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	p.Logger.Infof("Waiting for %d %s resources to be %s", len(pending), mgr.Name, status)
+	ctx, cancel := context.WithTimeout(ctx, cfg.WaitTimeout)
+	defer cancel()
+	lastStatus := make(map[*resource.Resource]string)
+	for {
+		current, err := p.poll(ctx, mgr, pending)
+		if err != nil {
+			p.Logger.Warnf("Could not poll %s resources: %s", mgr.Name, err)
+		} else {
+			for r, start := range pending {
+				found := resource.Find(current, r)
+				if converged(mgr, found, status) {
+					results.Succeeded(r, start)
+					delete(pending, r)
+					continue
+				}
+				lastStatus[r] = "not found"
+				if found != nil {
+					lastStatus[r] = found.Status.String()
+				}
+			}
+		}
+		if len(pending) == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			for r, start := range pending {
+				err := fmt.Errorf("Did not become %s within %s, last status %s", status, cfg.WaitTimeout, lastStatus[r])
+				p.Logger.Errorf("%s: %s", r, err)
+				results.Failed(r, err, start)
+			}
+			return
+		case <-time.After(cfg.WaitInterval):
+		}
+	}
+}
+
+// poll lists the resources of mgr in the accounts and regions of pending, or all resources of mgr if it cannot
+// list resources in specific accounts and regions
+func (p *Provider) poll(ctx context.Context, mgr *resource.Manager, pending map[*resource.Resource]time.Time) ([]*resource.Resource, error) {
+	ctx, cancel := withOperationTimeout(ctx)
+	defer cancel()
+	if mgr.GetAllIn == nil {
+		return mgr.GetAll(ctx)
+	}
+	resources := make([]*resource.Resource, 0, len(pending))
+	for r := range pending {
+		resources = append(resources, r)
+	}
+	return mgr.GetAllIn(ctx, resources)
+}
+
+// converged checks if res reached status. res is nil when it was not found
+func converged(mgr *resource.Manager, res *resource.Resource, status resource.Status) bool {
+	if res == nil {
+		return status == resource.Destroyed
+	}
+	if mgr.Converged != nil {
+		return mgr.Converged(res, status)
+	}
+	return res.Status == status
+}
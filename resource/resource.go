@@ -1,6 +1,8 @@
 package resource
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -21,12 +23,20 @@ type Manager struct {
 
 	Logger *log.Entry `gorm:"-"`
 
-	// Methods Implemented By Resource Manager
-	GetAll func() ([]*Resource, error) `gorm:"-" json:"-"` // Required
+	// Methods Implemented By Resource Manager. Calls made by them should stop when ctx is done. Actions record
+	// the resources which were skipped or failed in results, and return an error if the whole batch failed
+	GetAll func(ctx context.Context) ([]*Resource, error) `gorm:"-" json:"-"` // Required
+	// GetAllIn lists the resources in the accounts and regions of resources. It is optional, and GetAll is used
+	// when it is nil
+	GetAllIn func(ctx context.Context, resources []*Resource) ([]*Resource, error) `gorm:"-" json:"-"`
 
-	Destroy func([]*Resource) error `gorm:"-" json:"-"` // Required
-	Stop    func([]*Resource) error `gorm:"-" json:"-"`
-	Resume  func([]*Resource) error `gorm:"-" json:"-"`
+	Destroy func(ctx context.Context, resources []*Resource, results *Results) error `gorm:"-" json:"-"` // Required
+	Stop    func(ctx context.Context, resources []*Resource, results *Results) error `gorm:"-" json:"-"`
+	Resume  func(ctx context.Context, resources []*Resource, results *Results) error `gorm:"-" json:"-"`
+
+	// Converged checks if res reached the expected status after an action. It is optional, and the status of res is
+	// compared with expected when it is nil. Resources which are not found have been destroyed
+	Converged func(res *Resource, expected Status) bool `gorm:"-" json:"-"`
 }
 
 func (mgr Manager) String() string {
@@ -46,13 +56,17 @@ type Resource struct {
 	gorm.Model `json:"-"`
 	// UUID defines any unique field use to identify a resource. For some resources its their IDs, some their names.
 	// Not using ID because gorm.Model defines an ID field already
-	UUID         string   `gorm:"unique;not null"`
+	// UUIDs such as names of EKS clusters repeat across accounts and regions, so resources are identified by their
+	// account, location and UUID. See Key
+	UUID         string   `gorm:"not null;uniqueIndex:idx_resource_key"`
 	Manager      *Manager `gorm:"foreignKey:ManagerName;references:Name" json:"-"`
 	ProviderName string
+	// AccountID is the account (AWS) or project (GCP) the resource belongs to
+	AccountID string `gorm:"uniqueIndex:idx_resource_key"`
 
-	Region   string // Region of Resource
-	Zone     string // Zone of Resource
-	Location string // Location of Resource. Sometimes this is set to Region or Zone depending on the Resource itself
+	Region   string `gorm:"uniqueIndex:idx_resource_key"` // Region of Resource
+	Zone     string `gorm:"uniqueIndex:idx_resource_key"` // Zone of Resource
+	Location string `gorm:"uniqueIndex:idx_resource_key"` // Location of Resource. Sometimes this is set to Region or Zone depending on the Resource itself
 
 	// The state of the instance; stopped, running, pending
 	Status Status
@@ -69,6 +83,9 @@ type Resource struct {
 	// an independent resource e.g Nodegroups are subresource of EKS Clusters. Destroying an EKS Cluster
 	// destroys all nodegroups associated with it
 	SubResources map[string][]*Resource
+
+	// LastAction is the outcome of the last action reka took on the resource
+	LastAction *LastAction `gorm:"-" json:",omitempty"`
 }
 
 func (r Resource) String() string {
@@ -89,6 +106,69 @@ func (r Resource) IsUnused() bool {
 	return r.Status == Unused
 }
 
+// IntAttribute returns the attribute name as an integer. Numbers in attributes read from state are decoded as float64,
+// so any numeric type is accepted. ok is false if the attribute is not set or is not a number
+func (r Resource) IntAttribute(name string) (value int64, ok bool) {
+	switch v := r.Attributes[name].(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// Scope returns the most specific location of the resource. It is its zone, location or region in that order
+func (r Resource) Scope() string {
+	for _, l := range []string{r.Zone, r.Location, r.Region} {
+		if l != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+// Key identifies the resource within its provider and manager in the form <account>/<scope>/<uuid> e.g
+// 123456789012/us-east-1/my-cluster
+func (r Resource) Key() string {
+	return strings.Join([]string{r.AccountID, r.Scope(), r.UUID}, "/")
+}
+
+// ParseKey returns a resource with the account, scope and UUID of key. A key without slashes is a UUID alone
+func ParseKey(key string) *Resource {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) < 3 {
+		return &Resource{UUID: key}
+	}
+	return &Resource{AccountID: parts[0], Location: parts[1], UUID: parts[2]}
+}
+
+// Is checks if other is the same resource. The account and scope are only compared when they are set on both, as
+// resources tracked before they were recorded have neither
+func (r Resource) Is(other *Resource) bool {
+	same := func(a, b string) bool {
+		return a == "" || b == "" || a == b
+	}
+	return r.UUID == other.UUID && same(r.AccountID, other.AccountID) && same(r.Scope(), other.Scope())
+}
+
+// Find returns the resource in resources which is the same resource as res
+func Find(resources []*Resource, res *Resource) *Resource {
+	for _, r := range resources {
+		if r.Is(res) {
+			return r
+		}
+	}
+	return nil
+}
+
 // Uri a simple uri of the resource in the form provider.resource_type for example ec2 instances will have the
 // url aws.ec2
 func (r Resource) Uri() string {
@@ -0,0 +1,108 @@
+package resource
+
+import (
+	"sync"
+	"time"
+)
+
+// Outcome is the outcome of an action on a resource
+type Outcome string
+
+const (
+	Succeeded Outcome = "succeeded"
+	Skipped   Outcome = "skipped"
+	Failed    Outcome = "failed"
+)
+
+// Result is the outcome of an action on a single resource
+type Result struct {
+	Resource *Resource
+	Outcome  Outcome
+	// Reason the resource was skipped or failed
+	Reason   string
+	Duration time.Duration
+}
+
+// LastAction is the outcome of the last action reka took on a resource. It is kept in state so failed and
+// skipped actions can be reviewed after a run
+type LastAction struct {
+	Action  string
+	Outcome Outcome
+	Reason  string `json:",omitempty"`
+	Time    time.Time
+}
+
+// Record sets the last action of the resource of the result to action
+func (r *Result) Record(action string) {
+	r.Resource.LastAction = &LastAction{Action: action, Outcome: r.Outcome, Reason: r.Reason, Time: time.Now()}
+}
+
+// Results collects the results of an action on resources. It is safe for concurrent use
+type Results struct {
+	mu      sync.Mutex
+	results map[*Resource]*Result
+	order   []*Resource
+}
+
+// NewResults returns an empty collection of results
+func NewResults() *Results {
+	return &Results{results: make(map[*Resource]*Result)}
+}
+
+func (r *Results) add(result *Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.results[result.Resource]; !ok {
+		r.order = append(r.order, result.Resource)
+	}
+	r.results[result.Resource] = result
+}
+
+// Succeeded records that the action on res which began at start succeeded
+func (r *Results) Succeeded(res *Resource, start time.Time) {
+	r.add(&Result{Resource: res, Outcome: Succeeded, Duration: time.Since(start)})
+}
+
+// Skipped records that no action was taken on res
+func (r *Results) Skipped(res *Resource, reason string) {
+	r.add(&Result{Resource: res, Outcome: Skipped, Reason: reason})
+}
+
+// Failed records that the action on res which began at start failed with err
+func (r *Results) Failed(res *Resource, err error, start time.Time) {
+	r.add(&Result{Resource: res, Outcome: Failed, Reason: err.Error(), Duration: time.Since(start)})
+}
+
+// Get returns the result recorded for res
+func (r *Results) Get(res *Resource) (*Result, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result, ok := r.results[res]
+	return result, ok
+}
+
+// Complete records resources without a result as failed with err, or as succeeded if err is nil. Actions on a
+// batch of resources only need to record the resources whose outcome differs from the batch
+func (r *Results) Complete(resources []*Resource, err error, start time.Time) {
+	for _, res := range resources {
+		if _, ok := r.Get(res); ok {
+			continue
+		}
+		if err != nil {
+			r.Failed(res, err, start)
+		} else {
+			r.Succeeded(res, start)
+		}
+	}
+}
+
+// List returns the results in the order they were recorded
+func (r *Results) List() []*Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*Result, 0, len(r.order))
+	for _, res := range r.order {
+		list = append(list, r.results[res])
+	}
+	return list
+}
@@ -1,15 +1,14 @@
 // Code generated by "enumer -type=Status ./status.go"; DO NOT EDIT.
 
-//
 package resource
 
 import (
 	"fmt"
 )
 
-const _StatusName = "PendingRunningShuttingDownDestroyedStoppingStoppedError"
+const _StatusName = "PendingRunningShuttingDownDestroyedStoppingStoppedUnusedError"
 
-var _StatusIndex = [...]uint8{0, 7, 14, 26, 35, 43, 50, 55}
+var _StatusIndex = [...]uint8{0, 7, 14, 26, 35, 43, 50, 56, 61}
 
 func (i Status) String() string {
 	if i < 0 || i >= Status(len(_StatusIndex)-1) {
@@ -18,7 +17,7 @@ func (i Status) String() string {
 	return _StatusName[_StatusIndex[i]:_StatusIndex[i+1]]
 }
 
-var _StatusValues = []Status{0, 1, 2, 3, 4, 5, 6}
+var _StatusValues = []Status{0, 1, 2, 3, 4, 5, 6, 7}
 
 var _StatusNameToValueMap = map[string]Status{
 	_StatusName[0:7]:   0,
@@ -27,7 +26,8 @@ var _StatusNameToValueMap = map[string]Status{
 	_StatusName[26:35]: 3,
 	_StatusName[35:43]: 4,
 	_StatusName[43:50]: 5,
-	_StatusName[50:55]: 6,
+	_StatusName[50:56]: 6,
+	_StatusName[56:61]: 7,
 }
 
 // StatusString retrieves an enum value from the enum constants string name.
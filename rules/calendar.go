@@ -0,0 +1,316 @@
+package rules
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mensaah/reka/config"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	calendars       map[string]*calendar
+	freezeCalendars []*calendar
+)
+
+// day is a calendar day in the form YYYYMMDD. It does not belong to any timezone, so the days of a
+// calendar are the same days wherever a rule is evaluated
+type day int
+
+func dayOf(t time.Time) day {
+	return day(t.Year()*10000 + int(t.Month())*100 + t.Day())
+}
+
+// dayRange is an inclusive range of days
+type dayRange struct {
+	from, to day
+}
+
+// period is a span of time which starts at start and ends before end
+type period struct {
+	start, end time.Time
+}
+
+// calendar is a named set of days or periods e.g public holidays or change freezes
+type calendar struct {
+	name    string
+	days    []dayRange
+	periods []period
+}
+
+// contains checks if t falls on a day or within a period of the calendar
+func (c *calendar) contains(t time.Time) bool {
+	d := dayOf(t)
+	for _, r := range c.days {
+		if d >= r.from && d <= r.to {
+			return true
+		}
+	}
+	for _, p := range c.periods {
+		if !t.Before(p.start) && t.Before(p.end) {
+			return true
+		}
+	}
+	return false
+}
+
+func newCalendar(cfg *config.Calendar) (*calendar, error) {
+	c := &calendar{name: cfg.Name}
+	for _, d := range cfg.Dates {
+		r, err := parseDayRange(d)
+		if err != nil {
+			return nil, err
+		}
+		c.days = append(c.days, r)
+	}
+	if cfg.File != "" {
+		if err := c.readICS(cfg.File); err != nil {
+			return nil, fmt.Errorf("Could not read %s: %s", cfg.File, err)
+		}
+	}
+	return c, nil
+}
+
+// parseDayRange parses days in the form 2006-01-02 or ranges in the form 2006-01-02/2006-01-10
+func parseDayRange(value string) (dayRange, error) {
+	bounds := strings.Split(strings.TrimSpace(value), "/")
+	if len(bounds) > 2 {
+		return dayRange{}, fmt.Errorf("Invalid range of dates %s", value)
+	}
+	var days []day
+	for _, b := range bounds {
+		t, err := time.Parse(dateLayout, strings.TrimSpace(b))
+		if err != nil {
+			return dayRange{}, fmt.Errorf("Invalid date %s, format should be YYYY-MM-DD", b)
+		}
+		days = append(days, dayOf(t))
+	}
+	r := dayRange{from: days[0], to: days[len(days)-1]}
+	if r.to < r.from {
+		return dayRange{}, fmt.Errorf("Invalid range of dates %s, end is before start", value)
+	}
+	return r, nil
+}
+
+// readICS adds the events of an iCalendar file to the calendar. All day events cover whole days and
+// timed events cover the period between their start and end, set by DTEND or DURATION. Recurring events
+// are not supported
+func (c *calendar) readICS(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Long lines are folded into lines beginning with a space or tab
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	var (
+		inEvent    bool
+		start, end icsTime
+		duration   time.Duration
+	)
+	for _, line := range lines {
+		name, params, value := parseICSLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent, start, end, duration = true, icsTime{}, icsTime{}, 0
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+			if end.t.IsZero() && duration > 0 {
+				end = icsTime{t: start.t.Add(duration), allDay: start.allDay}
+			}
+			if err := c.addEvent(start, end); err != nil {
+				return err
+			}
+		case !inEvent:
+			continue
+		case name == "DTSTART":
+			if start, err = parseICSTime(params, value); err != nil {
+				return err
+			}
+		case name == "DTEND":
+			if end, err = parseICSTime(params, value); err != nil {
+				return err
+			}
+		case name == "DURATION":
+			if duration, err = parseICSDuration(value); err != nil {
+				return err
+			}
+		case name == "RRULE":
+			log.Warnf("Calendar %s: recurring events are not supported, only the first occurrence is used", c.name)
+		}
+	}
+	return nil
+}
+
+// addEvent adds an event which ends at end. As in RFC 5545, all day events without an end last a day and
+// timed events without an end are an instant, which no time falls within
+func (c *calendar) addEvent(start, end icsTime) error {
+	if start.t.IsZero() {
+		return fmt.Errorf("Event without DTSTART")
+	}
+	if start.allDay {
+		// The end of all day events is exclusive
+		to := start.t
+		if !end.t.IsZero() && end.t.After(start.t) {
+			to = end.t.AddDate(0, 0, -1)
+		}
+		c.days = append(c.days, dayRange{from: dayOf(start.t), to: dayOf(to)})
+		return nil
+	}
+	if end.t.IsZero() {
+		log.Debugf("Calendar %s: event at %s has no DTEND or DURATION and covers no time", c.name, start.t)
+		return nil
+	}
+	if end.t.After(start.t) {
+		c.periods = append(c.periods, period{start: start.t, end: end.t})
+	}
+	return nil
+}
+
+// parseICSDuration parses positive durations of an iCalendar property e.g P1D, PT1H30M or P2W
+func parseICSDuration(value string) (time.Duration, error) {
+	invalid := fmt.Errorf("Invalid DURATION %s", value)
+	v := strings.TrimPrefix(value, "+")
+	if !strings.HasPrefix(v, "P") || len(v) < 3 {
+		return 0, invalid
+	}
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	var (
+		d time.Duration
+		n int
+		// digits is set while a number is read, so units without one are invalid
+		digits bool
+	)
+	for i := 1; i < len(v); i++ {
+		switch ch := v[i]; {
+		case ch >= '0' && ch <= '9':
+			n, digits = n*10+int(ch-'0'), true
+		case ch == 'T' && !digits:
+			units = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		default:
+			unit, ok := units[ch]
+			if !ok || !digits {
+				return 0, invalid
+			}
+			d += time.Duration(n) * unit
+			n, digits = 0, false
+		}
+	}
+	if digits {
+		return 0, invalid
+	}
+	return d, nil
+}
+
+// icsTime is a DATE or DATE-TIME value of an iCalendar property
+type icsTime struct {
+	t      time.Time
+	allDay bool
+}
+
+// parseICSLine splits a content line e.g DTSTART;VALUE=DATE:20201225 into its name, parameters and value
+func parseICSLine(line string) (string, map[string]string, string) {
+	params := make(map[string]string)
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", params, ""
+	}
+	fields := strings.Split(line[:i], ";")
+	for _, f := range fields[1:] {
+		if kv := strings.SplitN(f, "=", 2); len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return strings.ToUpper(fields[0]), params, strings.TrimSpace(line[i+1:])
+}
+
+// parseICSTime parses dates e.g 20201225, UTC times e.g 20201224T090000Z and local times. Local times are in
+// the zone set by the TZID parameter, or the configured timezone if it is not set
+func parseICSTime(params map[string]string, value string) (icsTime, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		return icsTime{t: t, allDay: true}, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return icsTime{t: t}, err
+	}
+	loc := config.GetLocation()
+	if tzid, ok := params["TZID"]; ok {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return icsTime{}, fmt.Errorf("Invalid TZID %s: %s", tzid, err)
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return icsTime{t: t}, err
+}
+
+// loadCalendars loads calendars and the change freeze from config
+func loadCalendars() error {
+	cfg := config.GetConfig()
+	calendars = make(map[string]*calendar)
+	for _, c := range cfg.Calendars {
+		if _, ok := calendars[c.Name]; ok {
+			return fmt.Errorf("Calendar with name `%s` already exists", c.Name)
+		}
+		cal, err := newCalendar(c)
+		if err != nil {
+			return fmt.Errorf("Calendar `%s`: %s", c.Name, err)
+		}
+		calendars[c.Name] = cal
+	}
+
+	freezeCalendars = nil
+	for _, name := range cfg.Freeze {
+		cal, ok := calendars[name]
+		if !ok {
+			return fmt.Errorf("Freeze calendar `%s` is not defined in calendars", name)
+		}
+		freezeCalendars = append(freezeCalendars, cal)
+	}
+	return nil
+}
+
+func getCalendars(names []string) ([]*calendar, error) {
+	var cals []*calendar
+	for _, name := range names {
+		cal, ok := calendars[name]
+		if !ok {
+			return nil, fmt.Errorf("Calendar `%s` is not defined in calendars", name)
+		}
+		cals = append(cals, cal)
+	}
+	return cals, nil
+}
+
+// IsFrozen checks if a change freeze is in effect. No resources are destroyed during a change freeze
+func IsFrozen() bool {
+	currentTime := timeNow().In(config.GetLocation())
+	for _, c := range freezeCalendars {
+		if c.contains(currentTime) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+package rules
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/resource"
+)
+
+// writeICS writes lines to a temporary iCalendar file and returns its path
+func writeICS(t *testing.T, lines ...string) string {
+	t.Helper()
+	f, err := ioutil.TempFile("", "reka-*.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	if _, err := f.WriteString(strings.Join(lines, "\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestParseDayRange(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    dayRange
+		wantErr bool
+	}{
+		{value: "2020-12-25", want: dayRange{from: 20201225, to: 20201225}},
+		{value: "2020-12-24/2021-01-01", want: dayRange{from: 20201224, to: 20210101}},
+		{value: " 2020-12-24 / 2020-12-26 ", want: dayRange{from: 20201224, to: 20201226}},
+		{value: "2020-12-26/2020-12-24", wantErr: true},
+		{value: "2020-12-24/2020-12-25/2020-12-26", wantErr: true},
+		{value: "25-12-2020", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDayRange(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDayRange(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDayRange(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseICSDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "P1D", want: 24 * time.Hour},
+		{value: "P2W", want: 14 * 24 * time.Hour},
+		{value: "PT1H30M", want: 90 * time.Minute},
+		{value: "+P1DT12H", want: 36 * time.Hour},
+		{value: "PT45S", want: 45 * time.Second},
+		{value: "P", wantErr: true},
+		{value: "1D", wantErr: true},
+		{value: "P1H", wantErr: true},
+		{value: "PT1D", wantErr: true},
+		{value: "PD", wantErr: true},
+		{value: "P1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseICSDuration(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseICSDuration(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseICSDuration(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseICSLine(t *testing.T) {
+	name, params, value := parseICSLine(`DTSTART;TZID="Europe/Berlin";VALUE=DATE-TIME:20201224T090000`)
+	if name != "DTSTART" || value != "20201224T090000" {
+		t.Errorf("parseICSLine() = %s, %s", name, value)
+	}
+	if params["TZID"] != "Europe/Berlin" || params["VALUE"] != "DATE-TIME" {
+		t.Errorf("parseICSLine() params = %v", params)
+	}
+}
+
+func TestReadICS(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	path := writeICS(t,
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"SUMMARY:Christmas",
+		"DTSTART;VALUE=DATE:20201225",
+		"DTEND;VALUE=DATE:20201227",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:New year",
+		"DTSTART;VALUE=DATE:20210101",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:A summary which is long enough to be",
+		"  folded",
+		"DTSTART:20201230T",
+		" 090000Z",
+		"DTEND:20201230T120000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Release",
+		"DTSTART;TZID=Europe/Berlin:20210105T220000",
+		"DURATION:PT4H",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Reminder",
+		"DTSTART:20210110T090000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Offsite",
+		"DTSTART;VALUE=DATE:20210201",
+		"DURATION:P2D",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+	cal, err := newCalendar(&config.Calendar{Name: "holidays", File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{name: "first day of an all day event", t: time.Date(2020, time.December, 25, 10, 0, 0, 0, time.UTC), want: true},
+		{name: "last day of an all day event", t: time.Date(2020, time.December, 26, 23, 0, 0, 0, time.UTC), want: true},
+		{name: "exclusive end of an all day event", t: time.Date(2020, time.December, 27, 10, 0, 0, 0, time.UTC), want: false},
+		{name: "all day event without an end", t: time.Date(2021, time.January, 1, 10, 0, 0, 0, time.UTC), want: true},
+		{name: "day after an all day event without an end", t: time.Date(2021, time.January, 2, 10, 0, 0, 0, time.UTC), want: false},
+		{name: "within a folded timed event", t: time.Date(2020, time.December, 30, 9, 0, 0, 0, time.UTC), want: true},
+		{name: "end of a timed event", t: time.Date(2020, time.December, 30, 12, 0, 0, 0, time.UTC), want: false},
+		{name: "within a timed event with a duration", t: time.Date(2021, time.January, 6, 1, 30, 0, 0, berlin), want: true},
+		{name: "after a timed event with a duration", t: time.Date(2021, time.January, 6, 2, 0, 0, 0, berlin), want: false},
+		{name: "timed event without an end", t: time.Date(2021, time.January, 10, 9, 0, 0, 0, time.UTC), want: false},
+		{name: "last day of an all day event with a duration", t: time.Date(2021, time.February, 2, 10, 0, 0, 0, time.UTC), want: true},
+		{name: "after an all day event with a duration", t: time.Date(2021, time.February, 3, 10, 0, 0, 0, time.UTC), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.contains(tt.t); got != tt.want {
+				t.Errorf("contains(%s) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadICSErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{name: "event without DTSTART", lines: []string{"BEGIN:VEVENT", "DTEND;VALUE=DATE:20201226", "END:VEVENT"}},
+		{name: "invalid date", lines: []string{"BEGIN:VEVENT", "DTSTART;VALUE=DATE:2020-12-25", "END:VEVENT"}},
+		{name: "invalid TZID", lines: []string{"BEGIN:VEVENT", "DTSTART;TZID=Mars/Olympus:20201225T090000", "END:VEVENT"}},
+		{name: "invalid duration", lines: []string{"BEGIN:VEVENT", "DTSTART:20201225T090000Z", "DURATION:1H", "END:VEVENT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newCalendar(&config.Calendar{Name: "test", File: writeICS(t, tt.lines...)}); err == nil {
+				t.Error("newCalendar() expected an error")
+			}
+		})
+	}
+}
+
+func TestHolidayOvernightWindow(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	// 24 December 2020 is a Thursday
+	holidays := &calendar{name: "holidays", days: []dayRange{{from: 20201225, to: 20201225}}}
+	rule := &ActiveDurationRule{Rule: &Rule{
+		Rule:     &config.Rule{Name: "night-shift"},
+		location: time.UTC,
+		holidays: []*calendar{holidays},
+	}}
+	rule.Condition.ActiveDuration.StartTime = "22:00"
+	rule.Condition.ActiveDuration.StopTime = "06:00"
+	if err := rule.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		t      time.Time
+		status resource.Status
+		want   Action
+	}{
+		{name: "window before a holiday", t: time.Date(2020, time.December, 24, 23, 0, 0, 0, time.UTC), status: resource.Stopped, want: Resume},
+		{name: "window from the day before continues on a holiday", t: time.Date(2020, time.December, 25, 3, 0, 0, 0, time.UTC), status: resource.Running, want: DoNothing},
+		{name: "window starting on a holiday", t: time.Date(2020, time.December, 25, 23, 0, 0, 0, time.UTC), status: resource.Running, want: Stop},
+		{name: "window from a holiday continues the day after", t: time.Date(2020, time.December, 26, 3, 0, 0, 0, time.UTC), status: resource.Running, want: Stop},
+		{name: "window after a holiday", t: time.Date(2020, time.December, 26, 23, 0, 0, 0, time.UTC), status: resource.Stopped, want: Resume},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeNow = func() time.Time { return tt.t }
+			res := &resource.Resource{UUID: "i-1", Status: tt.status}
+			if got := rule.CheckResource(res); got != tt.want {
+				t.Errorf("CheckResource() at %s = %s, want %s", tt.t, got, tt.want)
+			}
+		})
+	}
+}
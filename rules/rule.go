@@ -1,7 +1,11 @@
+//go:generate enumer -type=Action -text ./rule.go
 package rules
 
 import (
-	"unsafe"
+	"fmt"
+	"path"
+	"sort"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -11,8 +15,10 @@ import (
 
 // Ruler interface for all rules
 type Ruler interface {
-	validate() error // Checks if the parameters passed are valid for the rule
+	validate() error                 // Checks if the parameters passed are valid for the rule
+	matches(*resource.Resource) bool // Checks if the rule targets a resource
 	CheckResource(*resource.Resource) Action
+	Config() *config.Rule // The rule as defined in config
 	String() string
 }
 
@@ -27,27 +33,59 @@ const (
 )
 
 var (
-	rules        map[string]Ruler
+	// rules are kept in the order they are evaluated in
+	rules        []Ruler
 	excludeRules []*config.ExcludeRule
 )
 
-func init() {
-	rules = make(map[string]Ruler)
+// severity ranks actions for the most-destructive conflict policy
+var severity = map[Action]int{
+	DoNothing: 0,
+	Resume:    1,
+	Stop:      2,
+	Destroy:   3,
 }
 
 // LoadRules loads exclude rules into memory. It is abstracted as a different function because at the time
 // of calling init, config values have not been loaded yet
 func LoadRules() error {
+	if err := loadCalendars(); err != nil {
+		return err
+	}
+
 	var err error
+	rules = nil
 	for _, rule := range config.GetConfig().Rules {
 		// Convert Rule in config to rules.Rule type
-		r := *((*Rule)(unsafe.Pointer(&rule)))
-		r.Tags = resource.Tags(rule.Tags)
+		r := Rule{Rule: rule}
+		r.location, err = config.LoadLocation(rule.Timezone)
+		if err != nil {
+			return fmt.Errorf("Rule `%s`: %s", rule.Name, err)
+		}
+		if rule.Timezone == "" {
+			r.location = config.GetLocation()
+		}
+		if r.holidays, err = getCalendars(rule.Holidays); err != nil {
+			return fmt.Errorf("Rule `%s`: %s", rule.Name, err)
+		}
+		if err = validateRegions(rule.GetRegions()); err != nil {
+			return fmt.Errorf("Rule `%s`: %s", rule.Name, err)
+		}
 		err = ParseRule(r)
 		if err != nil {
 			return err
 		}
 	}
+	for _, exRule := range config.GetConfig().Exclude {
+		if err = validateRegions(exRule.GetRegions()); err != nil {
+			return fmt.Errorf("Exclude rule `%s`: %s", exRule.Name, err)
+		}
+	}
+
+	// Rules with a higher priority are evaluated first. Rules with the same priority keep their order in config
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Config().Priority > rules[j].Config().Priority
+	})
 
 	excludeRules = config.GetConfig().Exclude
 	return nil
@@ -56,6 +94,48 @@ func LoadRules() error {
 // Rule that can be defined for resources
 type Rule struct {
 	*config.Rule
+	// location is the timezone time conditions of the rule are evaluated in
+	location *time.Location
+	// holidays are calendars of days resources are kept off on
+	holidays []*calendar
+}
+
+// now returns the current time in the timezone of the rule
+func (r Rule) now() time.Time {
+	if r.location == nil {
+		return timeNow().In(config.GetLocation())
+	}
+	return timeNow().In(r.location)
+}
+
+// isHoliday checks if t is on a holiday in any of the calendars of the rule. Rules pass the start of the
+// active period containing the current time, so overnight windows are not cut at midnight
+func (r Rule) isHoliday(t time.Time) bool {
+	for _, c := range r.holidays {
+		if c.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// destroy returns the Destroy action unless a change freeze is in effect
+func (r Rule) destroy() Action {
+	if IsFrozen() {
+		log.Debugf("Rule `%s`: Change freeze in effect, not destroying resources", r.Name)
+		return DoNothing
+	}
+	return Destroy
+}
+
+// Config returns the rule definition from config
+func (r Rule) Config() *config.Rule {
+	return r.Rule
+}
+
+// matches checks if the resource is targeted by the resources and tags of the rule
+func (r Rule) matches(res *resource.Resource) bool {
+	return !r.shouldExcludeResource(res) && hasTags(res, r.Tags)
 }
 
 // All checks for resource exclusion are to be done here.
@@ -64,9 +144,21 @@ func (r Rule) shouldExcludeResource(res *resource.Resource) bool {
 	if !hasResourceUri(r.Resources, res) {
 		return true
 	}
+	// Resources outside the regions or accounts of the rule are excluded
+	if !InRegions(res, r.GetRegions()) || !inAccounts(res, r.Accounts) {
+		return true
+	}
+
+	return IsExcluded(res)
+}
 
+// IsExcluded checks if a resource is not tracked by reka or matches any of the exclude rules
+func IsExcluded(res *resource.Resource) bool {
+	if !isTracked(res) {
+		return true
+	}
 	for _, exRule := range excludeRules {
-		if hasResourceUri(exRule.Resources, res) && hasTags(res, exRule.Tags) {
+		if hasResourceUri(exRule.Resources, res) && hasTags(res, exRule.Tags) && InRegions(res, exRule.GetRegions()) && inAccounts(res, exRule.Accounts) {
 			return true
 		}
 	}
@@ -75,21 +167,35 @@ func (r Rule) shouldExcludeResource(res *resource.Resource) bool {
 
 // ParseRule Get the rule to use for a particular condition
 func ParseRule(rule Rule) error {
-	r := []string{rule.Condition.ActiveDuration.StartTime, rule.Condition.TerminationDate, rule.Condition.TerminationPolicy}
+	activeDuration := ""
+	if rule.Condition.ActiveDuration.StartTime != "" || rule.Condition.ActiveDuration.StopTime != "" || len(rule.Condition.ActiveDuration.Windows) > 0 {
+		activeDuration = "activeDuration"
+	}
+	schedule := ""
+	if rule.Condition.Schedule.Resume != "" || rule.Condition.Schedule.Stop != "" {
+		schedule = "schedule"
+	}
+	r := []string{activeDuration, schedule, rule.Condition.TerminationDate, rule.Condition.TerminationPolicy, rule.Condition.MaxAge}
 	if hasMultipleConditions(r) {
 		log.Fatalf("Multiple Conditions specified for rule: `%s`", rule.Name)
 	}
-	if _, ok := rules[rule.Name]; ok {
-		log.Fatalf("Rule with name `%s` already exists", rule.Name)
+	for _, r := range rules {
+		if r.Config().Name == rule.Name {
+			log.Fatalf("Rule with name `%s` already exists", rule.Name)
+		}
 	}
 
 	var activeRule Ruler
-	if rule.Condition.ActiveDuration.StartTime != "" || rule.Condition.ActiveDuration.StopTime != "" {
+	if activeDuration != "" {
 		activeRule = &ActiveDurationRule{Rule: &rule}
+	} else if schedule != "" {
+		activeRule = &ScheduleRule{Rule: &rule}
 	} else if rule.Condition.TerminationDate != "" {
 		activeRule = &TerminationDateRule{Rule: &rule}
 	} else if rule.Condition.TerminationPolicy != "" {
 		activeRule = &TerminationPolicyRule{Rule: &rule}
+	} else if rule.Condition.MaxAge != "" {
+		activeRule = &MaxAgeRule{Rule: &rule}
 	} else {
 		log.Fatalf("No Conditions specified for rule: `%s`", rule.Name)
 	}
@@ -98,7 +204,7 @@ func ParseRule(rule Rule) error {
 	if err != nil {
 		return err
 	}
-	rules[rule.Name] = activeRule
+	rules = append(rules, activeRule)
 	return nil
 }
 
@@ -116,24 +222,69 @@ func hasMultipleConditions(rules []string) bool {
 	return false
 }
 
-// GetRules : Return an array of created Rules
+// GetRules : Return an array of created Rules in the order they are evaluated
 func GetRules() []Ruler {
-	var rulers []Ruler
-	for _, m := range rules {
-		rulers = append(rulers, m)
+	return append([]Ruler{}, rules...)
+}
+
+// GetResourceRule returns the rule which decides the action on a resource and the action. Excluded resources are
+// never acted on. Rules in config and `reka-*` tags on the resource are evaluated in the order set by `precedence`.
+// The first of them which resolves to an action other than DoNothing decides the action, so precedence only decides
+// between conflicting actions and a rule which leaves a resource alone does not block the other source. When several
+// rules in config resolve to different actions, the conflict policy decides the action:
+// - first-match: the action of the first rule in order of priority is used
+// - most-destructive: the most destructive action is used. Destroy beats Stop, which beats Resume
+func GetResourceRule(res *resource.Resource) (Ruler, Action) {
+	if IsExcluded(res) {
+		return nil, DoNothing
+	}
+	sources := [][]Ruler{GetRules(), {tagRule}}
+	if config.GetConfig().Precedence == config.TagsPrecedence {
+		sources[0], sources[1] = sources[1], sources[0]
 	}
-	return rulers
+	mostDestructive := config.GetConfig().ConflictPolicy == config.MostDestructive
+	// idle is the first rule which targets the resource but leaves it alone
+	var idle Ruler
+	for _, source := range sources {
+		var (
+			matched Ruler
+			action  Action
+		)
+		for _, r := range source {
+			if !r.matches(res) {
+				continue
+			}
+			a := r.CheckResource(res)
+			if matched == nil || severity[a] > severity[action] {
+				matched, action = r, a
+			}
+			if action != DoNothing && !mostDestructive {
+				break
+			}
+		}
+		if matched != nil && action != DoNothing {
+			return matched, action
+		}
+		if idle == nil {
+			idle = matched
+		}
+	}
+	return idle, DoNothing
 }
 
 // GetResourceAction get action to be performed on a resource
 func GetResourceAction(res *resource.Resource) Action {
-	// Returns the first Matching Rule Action for a resource
-	for _, r := range rules {
-		if action := r.CheckResource(res); action != DoNothing {
-			return action
-		}
+	_, action := GetResourceRule(res)
+	return action
+}
+
+// GetMatchingRule returns the rule which resolves to action for a resource. It is used to
+// report which rule is responsible for an action
+func GetMatchingRule(res *resource.Resource, action Action) Ruler {
+	if r, a := GetResourceRule(res); a == action {
+		return r
 	}
-	return DoNothing
+	return nil
 }
 
 func hasTags(res *resource.Resource, tags resource.Tags) bool {
@@ -156,3 +307,38 @@ func hasResourceUri(uris []string, res *resource.Resource) bool {
 	}
 	return true
 }
+
+// InRegions checks if the region, zone or location of a resource matches any of regions exactly or by wildcard
+// e.g eu-*. Providers set the region of zonal resources, so us-east1 also matches a resource in zone us-east1-b.
+// All resources match when regions is empty
+func InRegions(res *resource.Resource, regions []string) bool {
+	if len(regions) == 0 {
+		return true
+	}
+	for _, region := range regions {
+		for _, l := range []string{res.Region, res.Zone, res.Location} {
+			if l == "" {
+				continue
+			}
+			if ok, _ := path.Match(region, l); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inAccounts checks if a resource belongs to any of accounts. All resources belong when accounts is empty
+func inAccounts(res *resource.Resource, accounts []string) bool {
+	return len(accounts) == 0 || config.Contains(accounts, res.AccountID)
+}
+
+// validateRegions checks that regions are valid patterns
+func validateRegions(regions []string) error {
+	for _, region := range regions {
+		if _, err := path.Match(region, ""); err != nil {
+			return fmt.Errorf("Invalid region %s: %s", region, err)
+		}
+	}
+	return nil
+}
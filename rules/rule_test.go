@@ -0,0 +1,216 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/resource"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "90m", want: 90 * time.Minute},
+		{value: "24h", want: 24 * time.Hour},
+		{value: "7d", want: 7 * 24 * time.Hour},
+		{value: "2w", want: 14 * 24 * time.Hour},
+		{value: "1d12h", want: 36 * time.Hour},
+		{value: "1w2d", want: 9 * 24 * time.Hour},
+		{value: " 3d ", want: 3 * 24 * time.Hour},
+		{value: "", wantErr: true},
+		{value: "0d", wantErr: true},
+		{value: "-1h", wantErr: true},
+		{value: "xd", wantErr: true},
+		{value: "week", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAge(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAge(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestInRegions(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     *resource.Resource
+		regions []string
+		want    bool
+	}{
+		{name: "no regions", res: &resource.Resource{Region: "us-east-1"}, want: true},
+		{name: "exact region", res: &resource.Resource{Region: "us-east-1"}, regions: []string{"eu-west-1", "us-east-1"}, want: true},
+		{name: "other region", res: &resource.Resource{Region: "us-east-1"}, regions: []string{"us-east-2"}, want: false},
+		{name: "wildcard", res: &resource.Resource{Region: "eu-west-1"}, regions: []string{"eu-*"}, want: true},
+		{name: "wildcard for other regions", res: &resource.Resource{Region: "us-east-1"}, regions: []string{"eu-*"}, want: false},
+		{name: "region is not a prefix of other regions", res: &resource.Resource{Region: "us-east-1"}, regions: []string{"us"}, want: false},
+		{name: "region of a zone", res: &resource.Resource{Region: "us-east1", Zone: "us-east1-b"}, regions: []string{"us-east1"}, want: true},
+		{name: "zone", res: &resource.Resource{Region: "us-east1", Zone: "us-east1-b"}, regions: []string{"us-east1-b"}, want: true},
+		{name: "other zone", res: &resource.Resource{Region: "us-east1", Zone: "us-east1-b"}, regions: []string{"us-east1-c"}, want: false},
+		{name: "zone without region", res: &resource.Resource{Zone: "us-east1-b"}, regions: []string{"us-east1"}, want: false},
+		{name: "location", res: &resource.Resource{Location: "europe-west1"}, regions: []string{"europe-*"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InRegions(tt.res, tt.regions); got != tt.want {
+				t.Errorf("InRegions(%v) = %v, want %v", tt.regions, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRegions(t *testing.T) {
+	if err := validateRegions([]string{"us-east-1", "eu-*"}); err != nil {
+		t.Errorf("validateRegions() error = %v", err)
+	}
+	if err := validateRegions([]string{"eu-[west"}); err == nil {
+		t.Error("validateRegions() expected an error")
+	}
+}
+
+// newRule returns a rule named name with priority. set adds the condition of the rule
+func newRule(name string, priority int, set func(r *config.Rule)) *config.Rule {
+	r := &config.Rule{Name: name, Priority: priority}
+	set(r)
+	return r
+}
+
+func stopOutside(start, stop string) func(r *config.Rule) {
+	return func(r *config.Rule) {
+		r.Condition.ActiveDuration.StartTime, r.Condition.ActiveDuration.StopTime = start, stop
+	}
+}
+
+func maxAge(age string) func(r *config.Rule) {
+	return func(r *config.Rule) { r.Condition.MaxAge = age }
+}
+
+func TestGetResourceRule(t *testing.T) {
+	cfg := config.GetConfig()
+	defer func(c config.Config) {
+		*cfg = c
+		timeNow = time.Now
+		if err := LoadRules(); err != nil {
+			t.Fatal(err)
+		}
+	}(*cfg)
+	current := time.Date(2021, time.March, 10, 12, 0, 0, 0, config.GetLocation())
+	timeNow = func() time.Time { return current }
+
+	// Within 08:00-18:00 the rule leaves running resources alone, outside 08:00-09:00 it stops them
+	alone := stopOutside("08:00", "18:00")
+	stop := stopOutside("08:00", "09:00")
+	destroy := maxAge("7d")
+	pastDate := resource.Tags{"reka-destruction-date": "2021-03-01"}
+
+	tests := []struct {
+		name       string
+		rules      []*config.Rule
+		exclude    []*config.ExcludeRule
+		precedence string
+		policy     string
+		tags       resource.Tags
+		wantRule   string
+		want       Action
+	}{
+		{
+			name: "no rules",
+			want: DoNothing,
+		},
+		{
+			name:     "first match in config order",
+			rules:    []*config.Rule{newRule("stop", 0, stop), newRule("destroy", 0, destroy)},
+			wantRule: "stop", want: Stop,
+		},
+		{
+			name:     "first match in order of priority",
+			rules:    []*config.Rule{newRule("stop", 0, stop), newRule("destroy", 10, destroy)},
+			wantRule: "destroy", want: Destroy,
+		},
+		{
+			name:     "most destructive",
+			rules:    []*config.Rule{newRule("stop", 10, stop), newRule("destroy", 0, destroy)},
+			policy:   config.MostDestructive,
+			wantRule: "destroy", want: Destroy,
+		},
+		{
+			name:     "rules which leave a resource alone fall through",
+			rules:    []*config.Rule{newRule("alone", 10, alone), newRule("stop", 0, stop)},
+			wantRule: "stop", want: Stop,
+		},
+		{
+			name:     "rule which leaves a resource alone",
+			rules:    []*config.Rule{newRule("alone", 0, alone)},
+			wantRule: "alone", want: DoNothing,
+		},
+		{
+			name: "rules which do not target the resource",
+			rules: []*config.Rule{newRule("prod", 0, func(r *config.Rule) {
+				stop(r)
+				r.Tags = map[string]string{"env": "prod"}
+			}), newRule("other-region", 0, func(r *config.Rule) {
+				stop(r)
+				r.Regions = []string{"eu-*"}
+			})},
+			wantRule: "", want: DoNothing,
+		},
+		{
+			name:     "rules take precedence over tags",
+			rules:    []*config.Rule{newRule("stop", 0, stop)},
+			tags:     pastDate,
+			wantRule: "stop", want: Stop,
+		},
+		{
+			name:       "tags take precedence over rules",
+			rules:      []*config.Rule{newRule("stop", 0, stop)},
+			precedence: config.TagsPrecedence,
+			tags:       pastDate,
+			wantRule:   "reka-* tags", want: Destroy,
+		},
+		{
+			name:     "rules which leave a resource alone fall through to tags",
+			rules:    []*config.Rule{newRule("alone", 0, alone)},
+			tags:     pastDate,
+			wantRule: "reka-* tags", want: Destroy,
+		},
+		{
+			name:     "excluded resource",
+			rules:    []*config.Rule{newRule("stop", 0, stop)},
+			exclude:  []*config.ExcludeRule{{Name: "us", Regions: []string{"us-*"}}},
+			tags:     pastDate,
+			wantRule: "", want: DoNothing,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Rules, cfg.Exclude = tt.rules, tt.exclude
+			cfg.Precedence, cfg.ConflictPolicy = tt.precedence, tt.policy
+			if err := LoadRules(); err != nil {
+				t.Fatal(err)
+			}
+			res := &resource.Resource{
+				UUID:         "i-1",
+				Region:       "us-east-1",
+				Status:       resource.Running,
+				CreationDate: current.AddDate(0, 0, -10),
+				Tags:         tt.tags,
+			}
+			rule, action := GetResourceRule(res)
+			ruleName := ""
+			if rule != nil {
+				ruleName = rule.String()
+			}
+			if ruleName != tt.wantRule || action != tt.want {
+				t.Errorf("GetResourceRule() = %q, %s, want %q, %s", ruleName, action, tt.wantRule, tt.want)
+			}
+		})
+	}
+}
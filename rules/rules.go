@@ -2,10 +2,13 @@ package rules
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jinzhu/now"
 	"github.com/mensaah/reka/resource"
+	"github.com/robfig/cron/v3"
 )
 
 // TerminationDateRule defines rule that sets when a resource should be terminated based on termination-date rule
@@ -16,7 +19,7 @@ type TerminationDateRule struct {
 }
 
 func (r *TerminationDateRule) validate() error {
-	date, err := now.Parse(r.Condition.TerminationDate)
+	date, err := now.ParseInLocation(r.now().Location(), r.Condition.TerminationDate)
 	if err != nil {
 		return fmt.Errorf("Error parsing conditon.terminationDate: %s", err)
 	}
@@ -30,34 +33,27 @@ func (r TerminationDateRule) CheckResource(res *resource.Resource) Action {
 		return DoNothing
 	}
 	if hasTags(res, r.Tags) {
-		currentDate := time.Now()
-		if int(r.Date.Sub(currentDate)) <= 0 {
-			return Destroy
+		if !r.Date.After(r.now()) {
+			return r.destroy()
 		}
 	}
 	return DoNothing
 }
 
 // ActiveDurationRule defines the period within which a resource should be active. Any time
-// Outside the duration, the resource is stopped and resumed again when period is active
+// Outside the duration, the resource is stopped and resumed again when period is active.
+// The duration is a weekly schedule of one or more windows e.g 7:00 - 19:00 from Monday to Friday
 type ActiveDurationRule struct {
 	*Rule
-	StartTime time.Time
-	StopTime  time.Time
+	schedule weeklySchedule
 }
 
 func (r *ActiveDurationRule) validate() error {
-	startTime, err := now.Parse(r.Condition.ActiveDuration.StartTime)
+	schedule, err := newWeeklySchedule(r.Condition.ActiveDuration)
 	if err != nil {
-		return fmt.Errorf("Invalid Start Time for condition.activeDuration: %s", r.Condition.ActiveDuration.StartTime)
+		return fmt.Errorf("Invalid condition.activeDuration for rule `%s`: %s", r.Name, err)
 	}
-	r.StartTime = startTime
-
-	stopTime, err := now.Parse(r.Condition.ActiveDuration.StopTime)
-	if err != nil {
-		return fmt.Errorf("Invalid Stop Time for condition.activeDuration: %s", r.Condition.ActiveDuration.StopTime)
-	}
-	r.StopTime = stopTime
+	r.schedule = schedule
 	return nil
 }
 
@@ -67,13 +63,69 @@ func (r ActiveDurationRule) CheckResource(res *resource.Resource) Action {
 		return DoNothing
 	}
 	if hasTags(res, r.Tags) {
-		currentTime := time.Now()
+		active := r.isActive(r.now())
 		// Initialize Stopping only if currentTime is not within active duration
-		if !(currentTime.Sub(r.StartTime) > 0 && currentTime.Sub(r.StopTime) <= 0) && res.IsActive() {
+		if !active && res.IsActive() {
 			return Stop
 		}
 		// Initialize Resumption only if currentTime is within active duration
-		if currentTime.Sub(r.StartTime) > 0 && currentTime.Sub(r.StopTime) <= 0 && res.IsStopped() {
+		if active && res.IsStopped() {
+			return Resume
+		}
+	}
+	return DoNothing
+}
+
+// isActive checks if t is within a window of the schedule which did not start on a holiday
+func (r ActiveDurationRule) isActive(t time.Time) bool {
+	for _, start := range r.schedule.windowStarts(t) {
+		if !r.isHoliday(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScheduleRule stops and resumes resources at the times set by cron expressions. The resource should be
+// active if the most recent event of the schedule is a resume, and stopped if it is a stop
+type ScheduleRule struct {
+	*Rule
+	resume cron.Schedule
+	stop   cron.Schedule
+}
+
+func (r *ScheduleRule) validate() error {
+	var err error
+	if r.Condition.Schedule.Resume == "" || r.Condition.Schedule.Stop == "" {
+		return fmt.Errorf("Invalid condition.schedule for rule `%s`: resume and stop must be set together", r.Name)
+	}
+	if r.resume, err = parseCron(r.Condition.Schedule.Resume); err != nil {
+		return fmt.Errorf("Invalid condition.schedule.resume for rule `%s`: %s", r.Name, err)
+	}
+	if r.stop, err = parseCron(r.Condition.Schedule.Stop); err != nil {
+		return fmt.Errorf("Invalid condition.schedule.stop for rule `%s`: %s", r.Name, err)
+	}
+	return nil
+}
+
+// CheckResource Returns the action required for the resource to match the last event of the schedule
+func (r ScheduleRule) CheckResource(res *resource.Resource) Action {
+	if r.shouldExcludeResource(res) {
+		return DoNothing
+	}
+	if hasTags(res, r.Tags) {
+		currentTime := r.now()
+		lastResume, lastStop := lastEvent(r.resume, currentTime), lastEvent(r.stop, currentTime)
+		if lastResume.IsZero() && lastStop.IsZero() && !r.isHoliday(currentTime) {
+			return DoNothing
+		}
+		// A stop wins over a resume scheduled at the same time. Resources resumed on a holiday stay stopped
+		// until the next resume
+		active := lastResume.After(lastStop) && !r.isHoliday(lastResume)
+		if !active && res.IsActive() {
+			return Stop
+		}
+		if active && res.IsStopped() {
 			return Resume
 		}
 	}
@@ -97,13 +149,77 @@ func (r *TerminationPolicyRule) validate() error {
 	return fmt.Errorf("Error parsing condition.terminationPolicy: Invalid Policy %s", r.Policy)
 }
 
+// matches checks if the resource is targeted by the resources of the rule. Termination policies apply to all
+// resources of the rule whatever their tags
+func (r TerminationPolicyRule) matches(res *resource.Resource) bool {
+	return !r.shouldExcludeResource(res)
+}
+
 // CheckResource Returns a list of resources whose termination Date is exceeed
 func (r TerminationPolicyRule) CheckResource(res *resource.Resource) Action {
 	if r.shouldExcludeResource(res) {
 		return DoNothing
 	}
 	if res.IsUnused() {
-		return Destroy
+		return r.destroy()
 	}
 	return DoNothing
 }
+
+// MaxAgeRule destroys resources a period after they were created e.g 24h after creation
+type MaxAgeRule struct {
+	*Rule
+	MaxAge time.Duration
+}
+
+func (r *MaxAgeRule) validate() error {
+	maxAge, err := parseAge(r.Condition.MaxAge)
+	if err != nil {
+		return fmt.Errorf("Error parsing condition.maxAge for rule `%s`: %s", r.Name, err)
+	}
+	r.MaxAge = maxAge
+	return nil
+}
+
+// CheckResource Returns Destroy for resources older than the max age
+func (r MaxAgeRule) CheckResource(res *resource.Resource) Action {
+	if r.shouldExcludeResource(res) || res.CreationDate.IsZero() {
+		return DoNothing
+	}
+	if hasTags(res, r.Tags) {
+		if r.now().Sub(res.CreationDate) >= r.MaxAge {
+			return r.destroy()
+		}
+	}
+	return DoNothing
+}
+
+// parseAge parses durations e.g 90m, 24h. Days and weeks are also supported e.g 7d, 2w, 1d12h
+func parseAge(value string) (time.Duration, error) {
+	v := strings.TrimSpace(value)
+	var age time.Duration
+	for _, unit := range []struct {
+		suffix string
+		d      time.Duration
+	}{{"w", 7 * 24 * time.Hour}, {"d", 24 * time.Hour}} {
+		if i := strings.Index(v, unit.suffix); i >= 0 {
+			n, err := strconv.Atoi(v[:i])
+			if err != nil {
+				return 0, fmt.Errorf("Invalid age %s", value)
+			}
+			age += time.Duration(n) * unit.d
+			v = v[i+1:]
+		}
+	}
+	if v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid age %s, expected a duration e.g 24h, 7d", value)
+		}
+		age += d
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("Age %s must be greater than zero", value)
+	}
+	return age, nil
+}
@@ -0,0 +1,208 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mensaah/reka/config"
+	"github.com/robfig/cron/v3"
+)
+
+// maxLookback is how far back in time lastEvent searches for events of a cron schedule. It covers
+// schedules which run once a year or on leap days
+const maxLookback = 5 * 366 * 24 * time.Hour
+
+// timeNow returns the current time. Rules use it to evaluate time based conditions
+var timeNow = time.Now
+
+// clock is a time of the day in minutes after midnight
+type clock int
+
+func parseClock(value string) (clock, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("Invalid time %s, format should be HH:MM", value)
+	}
+	return clock(t.Hour()*60 + t.Minute()), nil
+}
+
+func clockOf(t time.Time) clock {
+	return clock(t.Hour()*60 + t.Minute())
+}
+
+func parseWeekday(value string) (time.Weekday, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if v == name || v == name[:3] {
+			return d, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("Invalid day %s", value)
+}
+
+// parseDays parses days or ranges of days e.g Monday, Mon-Fri, Friday-Monday. Ranges are inclusive and
+// can wrap around the end of the week
+func parseDays(values []string) ([7]bool, error) {
+	var days [7]bool
+	for _, value := range values {
+		bounds := strings.Split(value, "-")
+		if len(bounds) > 2 {
+			return days, fmt.Errorf("Invalid range of days %s", value)
+		}
+		start, err := parseWeekday(bounds[0])
+		if err != nil {
+			return days, err
+		}
+		stop := start
+		if len(bounds) == 2 {
+			if stop, err = parseWeekday(bounds[1]); err != nil {
+				return days, err
+			}
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == stop {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// window is a period of the day within which resources should be active on certain days of the week.
+// A window with stop before start crosses midnight and ends on the day after each of its days
+type window struct {
+	days  [7]bool
+	start clock
+	stop  clock
+}
+
+func newWindow(w config.ActiveWindow) (window, error) {
+	var (
+		win window
+		err error
+	)
+	if win.start, err = parseClock(w.StartTime); err != nil {
+		return win, fmt.Errorf("Invalid startTime: %s", err)
+	}
+	if win.stop, err = parseClock(w.StopTime); err != nil {
+		return win, fmt.Errorf("Invalid stopTime: %s", err)
+	}
+	if win.stop == win.start {
+		return win, fmt.Errorf("stopTime %s must be different from startTime %s", w.StopTime, w.StartTime)
+	}
+
+	days := append([]string{}, w.Days...)
+	switch {
+	case w.StartDay != "" && w.StopDay != "":
+		days = append(days, w.StartDay+"-"+w.StopDay)
+	case w.StartDay != "" || w.StopDay != "":
+		return win, fmt.Errorf("startDay and stopDay must be set together")
+	}
+	if len(days) == 0 {
+		// Active every day of the week
+		days = []string{"Sunday-Saturday"}
+	}
+	if win.days, err = parseDays(days); err != nil {
+		return win, err
+	}
+	return win, nil
+}
+
+// overnight checks if the window crosses midnight
+func (w window) overnight() bool {
+	return w.stop < w.start
+}
+
+// contains checks if t is within the window
+func (w window) contains(t time.Time) bool {
+	_, ok := w.startOf(t)
+	return ok
+}
+
+// startOf returns when the window which contains t started. ok is false if t is not within the window
+func (w window) startOf(t time.Time) (start time.Time, ok bool) {
+	c := clockOf(t)
+	day := t.Weekday()
+	if w.days[day] && c >= w.start && (w.overnight() || c < w.stop) {
+		return w.startOn(t, 0), true
+	}
+	// Before stop, t is in the part of a window which started the previous day
+	yesterday := (day + 6) % 7
+	if w.overnight() && w.days[yesterday] && c < w.stop {
+		return w.startOn(t, -1), true
+	}
+	return time.Time{}, false
+}
+
+// startOn returns the start of the window on the day offset days from t
+func (w window) startOn(t time.Time, offset int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+offset, int(w.start)/60, int(w.start)%60, 0, 0, t.Location())
+}
+
+// weeklySchedule is a set of windows within which resources should be active. Resources are active
+// when any of the windows contains the current time
+type weeklySchedule []window
+
+func newWeeklySchedule(d config.ActiveDuration) (weeklySchedule, error) {
+	var schedule weeklySchedule
+	windows := d.Windows
+	if d.StartTime != "" || d.StopTime != "" {
+		windows = append([]config.ActiveWindow{d.ActiveWindow}, windows...)
+	}
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("No active window specified")
+	}
+	for i, w := range windows {
+		win, err := newWindow(w)
+		if err != nil {
+			return nil, fmt.Errorf("window %d: %s", i+1, err)
+		}
+		schedule = append(schedule, win)
+	}
+	return schedule, nil
+}
+
+// windowStarts returns the start of each window of the schedule which contains t
+func (s weeklySchedule) windowStarts(t time.Time) []time.Time {
+	var starts []time.Time
+	for _, w := range s {
+		if start, ok := w.startOf(t); ok {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// parseCron parses standard 5 field cron expressions. Descriptors such as @daily are supported but @every
+// is not, as its events are relative to when reka starts rather than fixed times
+func parseCron(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return nil, fmt.Errorf("@every is not supported, use a cron expression instead")
+	}
+	return schedule, nil
+}
+
+// lastEvent returns the most recent time before or at t when the schedule was activated. It returns a zero time
+// if the schedule has no event within maxLookback. The window searched doubles until an event is found so
+// frequent schedules need few iterations, and the last window searched is always maxLookback
+func lastEvent(schedule cron.Schedule, t time.Time) time.Time {
+	for lookback := time.Hour; ; lookback *= 2 {
+		if lookback > maxLookback {
+			lookback = maxLookback
+		}
+		var last time.Time
+		for next := schedule.Next(t.Add(-lookback)); !next.IsZero() && !next.After(t); next = schedule.Next(next) {
+			last = next
+		}
+		if !last.IsZero() || lookback == maxLookback {
+			return last
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mensaah/reka/config"
+)
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    clock
+		wantErr bool
+	}{
+		{value: "00:00", want: 0},
+		{value: "08:30", want: 8*60 + 30},
+		{value: " 23:59 ", want: 23*60 + 59},
+		{value: "8", wantErr: true},
+		{value: "24:00", wantErr: true},
+		{value: "noon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseClock(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseClock(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseClock(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseDays(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []time.Weekday
+		wantErr bool
+	}{
+		{name: "single day", values: []string{"Monday"}, want: []time.Weekday{time.Monday}},
+		{name: "short names", values: []string{"sun", "SAT"}, want: []time.Weekday{time.Sunday, time.Saturday}},
+		{name: "range", values: []string{"Mon-Fri"}, want: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{name: "range around the weekend", values: []string{"Friday-Monday"}, want: []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}},
+		{name: "days and ranges", values: []string{"Monday", "Wednesday-Thursday"}, want: []time.Weekday{time.Monday, time.Wednesday, time.Thursday}},
+		{name: "invalid day", values: []string{"Funday"}, wantErr: true},
+		{name: "invalid range", values: []string{"Mon-Tue-Wed"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDays(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDays(%v) error = %v, wantErr %v", tt.values, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var want [7]bool
+			for _, d := range tt.want {
+				want[d] = true
+			}
+			if got != want {
+				t.Errorf("parseDays(%v) = %v, want %v", tt.values, got, want)
+			}
+		})
+	}
+}
+
+func TestNewWindowErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		window config.ActiveWindow
+	}{
+		{name: "invalid startTime", window: config.ActiveWindow{StartTime: "8am", StopTime: "18:00"}},
+		{name: "invalid stopTime", window: config.ActiveWindow{StartTime: "08:00", StopTime: "6pm"}},
+		{name: "empty window", window: config.ActiveWindow{StartTime: "08:00", StopTime: "08:00"}},
+		{name: "startDay without stopDay", window: config.ActiveWindow{StartTime: "08:00", StopTime: "18:00", StartDay: "Monday"}},
+		{name: "invalid day", window: config.ActiveWindow{StartTime: "08:00", StopTime: "18:00", Days: []string{"Someday"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newWindow(tt.window); err == nil {
+				t.Errorf("newWindow(%+v) expected an error", tt.window)
+			}
+		})
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	// 12 March 2021 is a Friday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2021, time.March, day, hour, min, 0, 0, time.UTC)
+	}
+	weekdays := config.ActiveWindow{StartTime: "08:00", StopTime: "18:00", StartDay: "Monday", StopDay: "Friday"}
+	overnight := config.ActiveWindow{StartTime: "22:00", StopTime: "06:00", Days: []string{"Friday"}}
+	everyNight := config.ActiveWindow{StartTime: "22:00", StopTime: "06:00"}
+
+	tests := []struct {
+		name   string
+		window config.ActiveWindow
+		t      time.Time
+		want   bool
+	}{
+		{name: "within a day window", window: weekdays, t: at(12, 12, 0), want: true},
+		{name: "at the start of a day window", window: weekdays, t: at(12, 8, 0), want: true},
+		{name: "at the stop of a day window", window: weekdays, t: at(12, 18, 0), want: false},
+		{name: "before a day window", window: weekdays, t: at(12, 7, 59), want: false},
+		{name: "day window on the weekend", window: weekdays, t: at(13, 12, 0), want: false},
+		{name: "overnight window before midnight", window: overnight, t: at(12, 23, 0), want: true},
+		{name: "overnight window after midnight", window: overnight, t: at(13, 5, 59), want: true},
+		{name: "at the stop of an overnight window", window: overnight, t: at(13, 6, 0), want: false},
+		{name: "overnight window on another day", window: overnight, t: at(11, 23, 0), want: false},
+		{name: "morning of the start day of an overnight window", window: overnight, t: at(12, 5, 0), want: false},
+		{name: "overnight window every day", window: everyNight, t: at(14, 3, 0), want: true},
+		{name: "outside overnight window every day", window: everyNight, t: at(14, 12, 0), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := newWindow(tt.window)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.contains(tt.t); got != tt.want {
+				t.Errorf("contains(%s) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		t    time.Time
+		want time.Time
+	}{
+		{
+			name: "earlier the same day",
+			spec: "0 9 * * *",
+			t:    time.Date(2021, time.March, 10, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2021, time.March, 10, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "at the event",
+			spec: "0 9 * * *",
+			t:    time.Date(2021, time.March, 10, 9, 0, 0, 0, time.UTC),
+			want: time.Date(2021, time.March, 10, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "the previous day",
+			spec: "0 9 * * *",
+			t:    time.Date(2021, time.March, 10, 8, 0, 0, 0, time.UTC),
+			want: time.Date(2021, time.March, 9, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekdays over the weekend",
+			spec: "0 18 * * 1-5",
+			t:    time.Date(2021, time.March, 14, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2021, time.March, 12, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "yearly",
+			spec: "@yearly",
+			t:    time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day almost four years ago",
+			spec: "0 0 29 2 *",
+			t:    time.Date(2028, time.February, 28, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "no event",
+			spec: "0 0 30 2 *",
+			t:    time.Date(2021, time.March, 10, 0, 0, 0, 0, time.UTC),
+			want: time.Time{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCron(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := lastEvent(schedule, tt.t); !got.Equal(tt.want) {
+				t.Errorf("lastEvent(%q, %s) = %s, want %s", tt.spec, tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronEvery(t *testing.T) {
+	if _, err := parseCron("@every 1h"); err == nil {
+		t.Error("parseCron(@every 1h) expected an error")
+	}
+}
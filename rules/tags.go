@@ -2,10 +2,11 @@ package rules
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/jinzhu/now"
+	"github.com/mensaah/reka/config"
 	"github.com/mensaah/reka/resource"
 	log "github.com/sirupsen/logrus"
 )
@@ -16,12 +17,13 @@ const (
 	destructionPolicy  = "destruction-policy"
 	activeDurationTag  = "active-duration"
 	include            = "include"
+	exclude            = "exclude"
 )
 
-// Check if Tag is a valid Reka tag i.e begins with REKA_*
+// Check if Tag is a valid Reka tag i.e begins with reka-
 func isValidRekaTag(key string) bool {
 	k := strings.TrimSpace(strings.ToLower(key))
-	return strings.HasPrefix(k, rekaNamespace)
+	return strings.HasPrefix(k, rekaNamespace+"-")
 }
 
 // Check if Tag is a timer tag i.e. Tags that contain resource time control e.g destruction-date
@@ -50,97 +52,124 @@ func getCleanTag(key string) string {
 // - destruction-date: The date when all resources should be reaped e.g `YYYY-MM-DD HH:MM`; 10h (relative to starting time)
 // - destruction-policy: stop, destroy (Whether to just stop the resources or to destroy them)
 // - active-duration : Timeframe within which the Resource should be active e.g 10:30-18:00
-// - include: Use to explicitly add a resource to be tracked by reka
+// - include: Use to explicitly add a resource to be tracked by reka when `tracking` is exclude-all
+// - exclude: Use to explicitly stop reka from acting on a resource whatever the rules in config
+//
+// Tracking modes set with `tracking` in config
 // - exclude-all: Reka starts excluding all resources and they must be manually included to using `reka-include`
 // - include-all: Reka starts tracking all the resources
 
-// ShouldInitiateDestruction checks if the tags to initiate destruction are valid at the time of execution
-func ShouldInitiateDestruction(tags resource.Tags) bool {
-	// For destructionDate, check if current date is/surpassed destruction date
-	for k, v := range tags {
-		if isValidRekaTag(k) {
-			cleanedTag := getCleanTag(k)
-			if cleanedTag == destructionDateTag {
-				date, err := now.Parse(v)
-				if err != nil {
-					log.Errorf("Invalid Tag for destruction-date: %v, %s", v, err)
-					continue
-				}
-				currentDate := time.Now()
-				if int(date.Sub(currentDate)) <= 0 {
-					return true
-				}
-			}
-		}
+// isTracked checks if reka may act on a resource. Resources tagged `reka-exclude=true` are never tracked, and
+// resources must be tagged `reka-include=true` to be tracked when tracking is exclude-all
+func isTracked(res *resource.Resource) bool {
+	tags := getRekaTags(res.Tags)
+	if isTrue(tags[exclude]) {
+		return false
 	}
-	return false
+	if config.GetConfig().Tracking == config.ExcludeAll {
+		return isTrue(tags[include])
+	}
+	return true
 }
 
-func getCleanActiveDurationValue(value string) (time.Time, time.Time, error) {
-	v := strings.Split(value, "-")
-	if len(v) != 2 {
-		return time.Time{}, time.Time{}, fmt.Errorf("Invalid value for active-duration, format should be HH:MM-HH:MM: %v", v)
+func isTrue(value string) bool {
+	b, err := strconv.ParseBool(value)
+	return err == nil && b
+}
+
+// TagRule applies the lifecycle set by `reka-*` tags on resources. It lets resource owners schedule their
+// resources without rules in config
+type TagRule struct {
+	*Rule
+}
+
+// tagRule is evaluated for all resources alongside the rules in config
+var tagRule = &TagRule{Rule: &Rule{Rule: &config.Rule{Name: "reka-* tags"}}}
+
+func (r *TagRule) validate() error {
+	return nil
+}
+
+// matches checks if a resource has any lifecycle tag
+func (r TagRule) matches(res *resource.Resource) bool {
+	if IsExcluded(res) {
+		return false
 	}
-	startTime, err := now.Parse(v[0])
-	if err != nil {
-		return time.Time{}, time.Time{}, fmt.Errorf("Invalid Start Time for active-duration: %v", v)
+	tags := getRekaTags(res.Tags)
+	_, hasDate := tags[destructionDateTag]
+	_, hasDuration := tags[activeDurationTag]
+	return hasDate || hasDuration
+}
 
+// CheckResource Returns the action set by the lifecycle tags of a resource. Resources are destroyed once their
+// destruction-date is past, or stopped if their destruction-policy is stop. Resources with an active-duration are
+// stopped outside the period and resumed within it
+func (r TagRule) CheckResource(res *resource.Resource) Action {
+	if !r.matches(res) {
+		return DoNothing
 	}
-	stopTime, err := now.Parse(v[1])
-	if err != nil {
-		return time.Time{}, time.Time{}, fmt.Errorf("Invalid Stop Time for active-duration: %v", v)
+	tags := getRekaTags(res.Tags)
+	currentTime := r.now()
+
+	if v, ok := tags[destructionDateTag]; ok {
+		date, err := now.ParseInLocation(currentTime.Location(), v)
+		if err != nil {
+			log.Errorf("Invalid Tag for destruction-date on %s: %v, %s", res.UUID, v, err)
+		} else if !date.After(currentTime) {
+			if strings.ToLower(tags[destructionPolicy]) != "stop" {
+				return r.destroy()
+			}
+			if res.IsActive() {
+				return Stop
+			}
+			return DoNothing
+		}
+	}
+
+	if v, ok := tags[activeDurationTag]; ok {
+		w, err := getActiveDurationWindow(v)
+		if err != nil {
+			log.Errorf("Invalid Tag for active-duration on %s: %s", res.UUID, err)
+			return DoNothing
+		}
+		active := w.contains(currentTime)
+		if !active && res.IsActive() {
+			return Stop
+		}
+		if active && res.IsStopped() {
+			return Resume
+		}
 	}
-	return startTime, stopTime, nil
+	return DoNothing
 }
 
-// ShouldInitiateStopping checks if the tags to initiate destruction are valid at the time of execution
-func ShouldInitiateStopping(tag resource.Tags) bool {
-	for k, v := range tag {
+// getRekaTags returns the `reka-*` tags of a resource keyed by the tag without the namespace prefix
+func getRekaTags(tags resource.Tags) map[string]string {
+	rekaTags := make(map[string]string)
+	for k, v := range tags {
 		if isValidRekaTag(k) {
-			cleanedTag := getCleanTag(k)
-			// For activeDuration, check if current time is within active duration
-			// activeDuration specified does not destroy the instance. It justs stops the instance, if the instance
-			// implements the ResourceStopResumer Interface
-			if activeDurationTag == cleanedTag {
-				startTime, stopTime, err := getCleanActiveDurationValue(v)
-				if err != nil {
-					log.Errorf(err.Error())
-					continue
-				}
-				currentTime := time.Now()
-
-				// Initialize Stopping only if currentTime is not within active duration
-				if !(currentTime.Sub(startTime) > 0 && currentTime.Sub(stopTime) <= 0) {
-					return true
-				}
-			}
+			rekaTags[getCleanTag(k)] = strings.TrimSpace(v)
 		}
 	}
-	return false
+	return rekaTags
 }
 
-// ShouldInitiateResumption checks if the tags to initiate destruction are valid at the time of execution
-func ShouldInitiateResumption(tag resource.Tags) bool {
-	for k, v := range tag {
-		if isValidRekaTag(k) {
-			cleanedTag := getCleanTag(k)
-			// For activeDuration, check if current time is within active duration
-			// activeDuration specified does not destroy the instance. It justs stops the instance, if the instance
-			// implements the ResourceStopResumer Interface
-			if activeDurationTag == cleanedTag {
-				startTime, stopTime, err := getCleanActiveDurationValue(v)
-				if err != nil {
-					log.Errorf(err.Error())
-					continue
-				}
-				currentTime := time.Now()
-
-				// Initialize Resumption only if currentTime is within active duration
-				if currentTime.Sub(startTime) > 0 && currentTime.Sub(stopTime) <= 0 {
-					return true
-				}
-			}
+// getActiveDurationWindow parses active-duration tag values in the form HH:MM-HH:MM. As labels on GCP cannot
+// contain colons, HHMM-HHMM is also accepted. The window crosses midnight when the stop time is before the start
+// time e.g 20:00-06:00
+func getActiveDurationWindow(value string) (window, error) {
+	v := strings.Split(value, "-")
+	if len(v) != 2 {
+		return window{}, fmt.Errorf("Invalid value for active-duration, format should be HH:MM-HH:MM: %v", v)
+	}
+	for i := range v {
+		if t := strings.TrimSpace(v[i]); len(t) == 4 && !strings.Contains(t, ":") {
+			v[i] = t[:2] + ":" + t[2:]
 		}
 	}
-	return false
+	w, err := newWindow(config.ActiveWindow{StartTime: v[0], StopTime: v[1]})
+	if err != nil {
+		return window{}, fmt.Errorf("Invalid value for active-duration %s: %s", value, err)
+	}
+	return w, nil
 }
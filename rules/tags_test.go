@@ -0,0 +1,121 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/resource"
+)
+
+func TestGetRekaTags(t *testing.T) {
+	got := getRekaTags(resource.Tags{
+		"Reka-Destruction-Date": " 2021-01-01 ",
+		"reka-active-duration":  "08:00-18:00",
+		"owner":                 "team",
+		"reka":                  "true",
+	})
+	want := map[string]string{
+		destructionDateTag: "2021-01-01",
+		activeDurationTag:  "08:00-18:00",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("getRekaTags() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("getRekaTags()[%s] = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestIsTracked(t *testing.T) {
+	defer func(tracking string) { config.GetConfig().Tracking = tracking }(config.GetConfig().Tracking)
+	tests := []struct {
+		name     string
+		tracking string
+		tags     resource.Tags
+		want     bool
+	}{
+		{name: "include-all", tracking: config.IncludeAll, want: true},
+		{name: "include-all excluded", tracking: config.IncludeAll, tags: resource.Tags{"reka-exclude": "true"}, want: false},
+		{name: "include-all not excluded", tracking: config.IncludeAll, tags: resource.Tags{"reka-exclude": "false"}, want: true},
+		{name: "exclude-all", tracking: config.ExcludeAll, want: false},
+		{name: "exclude-all included", tracking: config.ExcludeAll, tags: resource.Tags{"reka-include": "true"}, want: true},
+		{name: "exclude-all included and excluded", tracking: config.ExcludeAll, tags: resource.Tags{"reka-include": "true", "reka-exclude": "true"}, want: false},
+		{name: "exclude-all invalid include", tracking: config.ExcludeAll, tags: resource.Tags{"reka-include": "yes"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.GetConfig().Tracking = tt.tracking
+			if got := isTracked(&resource.Resource{Tags: tt.tags}); got != tt.want {
+				t.Errorf("isTracked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetActiveDurationWindow(t *testing.T) {
+	tests := []struct {
+		value     string
+		start     clock
+		stop      clock
+		overnight bool
+		wantErr   bool
+	}{
+		{value: "08:00-18:00", start: 8 * 60, stop: 18 * 60},
+		{value: "0830-1730", start: 8*60 + 30, stop: 17*60 + 30},
+		{value: " 20:00 - 06:00 ", start: 20 * 60, stop: 6 * 60, overnight: true},
+		{value: "08:00", wantErr: true},
+		{value: "08:00-18:00-20:00", wantErr: true},
+		{value: "08:00-08:00", wantErr: true},
+		{value: "8am-6pm", wantErr: true},
+	}
+	for _, tt := range tests {
+		w, err := getActiveDurationWindow(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("getActiveDurationWindow(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if w.start != tt.start || w.stop != tt.stop || w.overnight() != tt.overnight {
+			t.Errorf("getActiveDurationWindow(%q) = %d-%d, want %d-%d", tt.value, w.start, w.stop, tt.start, tt.stop)
+		}
+	}
+}
+
+func TestTagRule(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	current := time.Date(2021, time.March, 10, 12, 0, 0, 0, config.GetLocation())
+	timeNow = func() time.Time { return current }
+
+	tests := []struct {
+		name   string
+		tags   resource.Tags
+		status resource.Status
+		want   Action
+	}{
+		{name: "no lifecycle tags", tags: resource.Tags{"reka-include": "true"}, status: resource.Running, want: DoNothing},
+		{name: "destruction-date passed", tags: resource.Tags{"reka-destruction-date": "2021-03-09"}, status: resource.Running, want: Destroy},
+		{name: "destruction-date in the future", tags: resource.Tags{"reka-destruction-date": "2021-03-11"}, status: resource.Running, want: DoNothing},
+		{name: "destruction-date passed with stop policy", tags: resource.Tags{"reka-destruction-date": "2021-03-09 10:00", "reka-destruction-policy": "stop"}, status: resource.Running, want: Stop},
+		{name: "destruction-date passed with stop policy when stopped", tags: resource.Tags{"reka-destruction-date": "2021-03-09", "reka-destruction-policy": "Stop"}, status: resource.Stopped, want: DoNothing},
+		{name: "invalid destruction-date", tags: resource.Tags{"reka-destruction-date": "someday"}, status: resource.Running, want: DoNothing},
+		{name: "within active-duration when stopped", tags: resource.Tags{"reka-active-duration": "08:00-18:00"}, status: resource.Stopped, want: Resume},
+		{name: "within active-duration when running", tags: resource.Tags{"reka-active-duration": "08:00-18:00"}, status: resource.Running, want: DoNothing},
+		{name: "outside active-duration when running", tags: resource.Tags{"reka-active-duration": "1300-1800"}, status: resource.Running, want: Stop},
+		{name: "outside overnight active-duration", tags: resource.Tags{"reka-active-duration": "20:00-06:00"}, status: resource.Running, want: Stop},
+		{name: "invalid active-duration", tags: resource.Tags{"reka-active-duration": "always"}, status: resource.Running, want: DoNothing},
+		{name: "excluded", tags: resource.Tags{"reka-destruction-date": "2021-03-09", "reka-exclude": "true"}, status: resource.Running, want: DoNothing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &resource.Resource{UUID: "i-1", Tags: tt.tags, Status: tt.status}
+			if got := tagRule.CheckResource(res); got != tt.want {
+				t.Errorf("CheckResource() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/provider/types"
+	"github.com/mensaah/reka/resource"
+	"github.com/mensaah/reka/rules"
+	"github.com/mensaah/reka/state"
+)
+
+// The types below define the JSON schema of the v1 API. Fields must not be renamed or removed
+// without introducing a new API version
+
+// ManagerJSON describes a resource type supported by a provider
+type ManagerJSON struct {
+	Name        string `json:"name"`
+	LongName    string `json:"longName"`
+	Destroyable bool   `json:"destroyable"`
+	Stoppable   bool   `json:"stoppable"`
+}
+
+// ProviderJSON describes an enabled provider and its resource types
+type ProviderJSON struct {
+	Name     string        `json:"name"`
+	Managers []ManagerJSON `json:"managers"`
+}
+
+// ResourceJSON is a resource tracked by reka
+type ResourceJSON struct {
+	URI          string            `json:"uri"`
+	ID           string            `json:"id"`
+	Provider     string            `json:"provider"`
+	Manager      string            `json:"manager"`
+	AccountID    string            `json:"accountId,omitempty"`
+	Region       string            `json:"region,omitempty"`
+	Zone         string            `json:"zone,omitempty"`
+	Location     string            `json:"location,omitempty"`
+	Status       string            `json:"status"`
+	CreationDate time.Time         `json:"creationDate"`
+	Tags         map[string]string `json:"tags"`
+}
+
+// StateJSON is the current and desired state of all resources. Resources are keyed by provider and manager
+type StateJSON struct {
+	Current map[string]map[string][]ResourceJSON `json:"current"`
+	Desired map[string]map[string][]ResourceJSON `json:"desired"`
+}
+
+// RuleJSON is a rule loaded from config
+type RuleJSON struct {
+	Kind string `json:"kind"`
+	*config.Rule
+}
+
+// ActionRequest is the body of a request to act on resources
+type ActionRequest struct {
+	// Action is one of stop, resume or destroy
+	Action string `json:"action"`
+	// Resources are resource URIs in the form <provider>.<manager>/<account>/<region or zone>/<id> e.g
+	// aws.ec2/123456789012/us-east-1/i-0123456789
+	Resources []string `json:"resources"`
+}
+
+// ActionResult is the outcome of an action on a single resource
+type ActionResult struct {
+	URI     string `json:"uri"`
+	Action  string `json:"action"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ErrorJSON is returned for all failed requests
+type ErrorJSON struct {
+	Error string `json:"error"`
+}
+
+// registerAPI adds the v1 API routes. auth is the middleware which authorizes requests, as all routes expose
+// the same inventory as the dashboard
+func (s *Server) registerAPI(router *gin.Engine, auth gin.HandlerFunc) {
+	v1 := router.Group("/api/v1", auth)
+	v1.GET("/providers", s.apiProviders)
+	v1.GET("/state", s.apiState)
+	v1.GET("/rules", s.apiRules)
+	v1.POST("/refresh", requireJSON, s.apiRefresh)
+	v1.POST("/actions", requireJSON, s.apiActions)
+}
+
+// requireJSON rejects requests which are not sent as JSON. Browsers cannot send JSON to other sites without a
+// preflight, so other sites cannot call the API with the dashboard credentials saved in the browser
+func requireJSON(c *gin.Context) {
+	if c.ContentType() != "application/json" {
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, ErrorJSON{Error: "Content-Type must be application/json"})
+		return
+	}
+	c.Next()
+}
+
+func (s *Server) apiProviders(c *gin.Context) {
+	providers := []ProviderJSON{}
+	for _, p := range s.providers {
+		pj := ProviderJSON{Name: p.Name, Managers: []ManagerJSON{}}
+		for _, name := range p.GetResourceNames() {
+			mgr := p.Managers[name]
+			pj.Managers = append(pj.Managers, ManagerJSON{
+				Name:        mgr.Name,
+				LongName:    mgr.LongName,
+				Destroyable: mgr.Destroy != nil,
+				Stoppable:   mgr.IsStoppable(),
+			})
+		}
+		sort.Slice(pj.Managers, func(i, j int) bool { return pj.Managers[i].Name < pj.Managers[j].Name })
+		providers = append(providers, pj)
+	}
+	c.JSON(http.StatusOK, providers)
+}
+
+func (s *Server) apiState(c *gin.Context) {
+	st := s.backend.GetState()
+	c.JSON(http.StatusOK, StateJSON{
+		Current: providersStateJSON(st.Current),
+		Desired: providersStateJSON(st.Desired),
+	})
+}
+
+func (s *Server) apiRules(c *gin.Context) {
+	rulesJSON := []RuleJSON{}
+	for _, r := range rules.GetRules() {
+		rulesJSON = append(rulesJSON, RuleJSON{Kind: ruleKind(r), Rule: r.Config()})
+	}
+	c.JSON(http.StatusOK, rulesJSON)
+}
+
+func (s *Server) apiRefresh(c *gin.Context) {
+	s.doRefresh(c.Request.Context())
+	s.apiState(c)
+}
+
+func (s *Server) apiActions(c *gin.Context) {
+	var req ActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorJSON{Error: fmt.Sprintf("Invalid request body: %s", err)})
+		return
+	}
+	action, err := parseAction(req.Action)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorJSON{Error: err.Error()})
+		return
+	}
+	if len(req.Resources) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorJSON{Error: "No resources specified"})
+		return
+	}
+
+	results := []ActionResult{}
+	for _, uri := range req.Resources {
+		result := ActionResult{URI: uri, Action: strings.ToLower(action.String())}
+		providerName, mgrName, key, err := parseResourceURI(uri)
+		if err == nil {
+			err = s.doAction(c.Request.Context(), providerName, mgrName, key, action)
+		}
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+func providersStateJSON(ps state.ProvidersState) map[string]map[string][]ResourceJSON {
+	out := make(map[string]map[string][]ResourceJSON)
+	for providerName, resources := range ps {
+		out[providerName] = resourcesJSON(providerName, resources)
+	}
+	return out
+}
+
+func resourcesJSON(providerName string, resources types.Resources) map[string][]ResourceJSON {
+	out := make(map[string][]ResourceJSON)
+	for mgrName, resList := range resources {
+		out[mgrName] = []ResourceJSON{}
+		for _, r := range resList {
+			out[mgrName] = append(out[mgrName], newResourceJSON(providerName, mgrName, r))
+		}
+	}
+	return out
+}
+
+func newResourceJSON(providerName, mgrName string, r *resource.Resource) ResourceJSON {
+	tags := map[string]string{}
+	for k, v := range r.Tags {
+		tags[k] = v
+	}
+	return ResourceJSON{
+		URI:          resourceURI(providerName, mgrName, r),
+		ID:           r.UUID,
+		Provider:     providerName,
+		Manager:      mgrName,
+		AccountID:    r.AccountID,
+		Region:       r.Region,
+		Zone:         r.Zone,
+		Location:     r.Location,
+		Status:       r.Status.String(),
+		CreationDate: r.CreationDate,
+		Tags:         tags,
+	}
+}
+
+func ruleKind(r rules.Ruler) string {
+	switch r.(type) {
+	case *rules.ActiveDurationRule:
+		return "activeDuration"
+	case *rules.ScheduleRule:
+		return "schedule"
+	case *rules.TerminationDateRule:
+		return "terminationDate"
+	case *rules.TerminationPolicyRule:
+		return "terminationPolicy"
+	case *rules.MaxAgeRule:
+		return "maxAge"
+	case *rules.TagRule:
+		return "tags"
+	default:
+		return "unknown"
+	}
+}
+
+// resourceURI returns the URI used to identify a resource in the API in the form
+// <provider>.<manager>/<account>/<region or zone>/<id>
+func resourceURI(providerName, mgrName string, r *resource.Resource) string {
+	return fmt.Sprintf("%s.%s/%s", providerName, mgrName, r.Key())
+}
+
+// parseResourceURI parses URIs in the form <provider>.<manager>/<account>/<region or zone>/<id>. The key of the
+// resource is returned after the provider and manager. <provider>.<manager>/<id> is accepted for IDs which are unique
+func parseResourceURI(uri string) (string, string, string, error) {
+	parts := strings.SplitN(uri, "/", 2)
+	if len(parts) == 2 {
+		kind := strings.SplitN(parts[0], ".", 2)
+		if len(kind) == 2 && kind[0] != "" && kind[1] != "" && parts[1] != "" {
+			return kind[0], kind[1], parts[1], nil
+		}
+	}
+	return "", "", "", fmt.Errorf("Invalid resource URI %s, expected <provider>.<manager>/<account>/<region or zone>/<id>", uri)
+}
+
+// parseAction converts actions in the form stop, resume, destroy to rules.Action
+func parseAction(a string) (rules.Action, error) {
+	action, err := rules.ActionString(strings.Title(strings.ToLower(a)))
+	if err != nil || action == rules.DoNothing {
+		return rules.DoNothing, fmt.Errorf("Invalid action %s, expected one of stop, resume or destroy", a)
+	}
+	return action, nil
+}
@@ -0,0 +1,199 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mensaah/reka/resource"
+	"github.com/mensaah/reka/rules"
+)
+
+type resourceView struct {
+	UUID          string
+	Key           string // Identifies the resource across accounts and regions
+	AccountID     string
+	Location      string
+	Status        resource.Status
+	DesiredStatus string
+	Action        rules.Action
+	Rule          string
+	Tracked       bool // Resource exists in the current state
+	CanStop       bool
+	CanResume     bool
+}
+
+type managerView struct {
+	Name      string
+	LongName  string
+	Resources []resourceView
+}
+
+type providerView struct {
+	Name     string
+	Managers []managerView
+}
+
+// buildViews groups all resources in the current and desired state by provider and manager
+func (s *Server) buildViews() []providerView {
+	st := s.backend.GetState()
+	var views []providerView
+	for _, p := range s.providers {
+		pv := providerView{Name: p.Name}
+		var mgrNames []string
+		for name := range p.Managers {
+			mgrNames = append(mgrNames, name)
+		}
+		sort.Strings(mgrNames)
+
+		for _, mgrName := range mgrNames {
+			mgr := p.Managers[mgrName]
+			mv := managerView{Name: mgr.Name, LongName: mgr.LongName}
+			current := st.Current[p.Name][mgrName]
+			desired := st.Desired[p.Name][mgrName]
+
+			for _, r := range current {
+				rv := resourceView{
+					UUID:      r.UUID,
+					Key:       r.Key(),
+					AccountID: r.AccountID,
+					Location:  location(r),
+					Status:    r.Status,
+					Tracked:   true,
+					CanStop:   mgr.IsStoppable() && r.IsActive(),
+					CanResume: mgr.IsStoppable() && r.IsStopped(),
+				}
+				if d := resource.Find(desired, r); d != nil {
+					rv.DesiredStatus = d.Status.String()
+				}
+				if rule, action := rules.GetResourceRule(r); rule != nil && action != rules.DoNothing {
+					rv.Action, rv.Rule = action, rule.String()
+				}
+				mv.Resources = append(mv.Resources, rv)
+			}
+			// Resources reka tracks which are no longer found on the provider
+			for _, d := range desired {
+				if resource.Find(current, d) == nil {
+					mv.Resources = append(mv.Resources, resourceView{
+						UUID:          d.UUID,
+						Key:           d.Key(),
+						AccountID:     d.AccountID,
+						Location:      location(d),
+						Status:        resource.Destroyed,
+						DesiredStatus: d.Status.String(),
+					})
+				}
+			}
+			sort.Slice(mv.Resources, func(i, j int) bool { return mv.Resources[i].Key < mv.Resources[j].Key })
+			pv.Managers = append(pv.Managers, mv)
+		}
+		views = append(views, pv)
+	}
+	return views
+}
+
+func (s *Server) dashboard(c *gin.Context) {
+	c.HTML(http.StatusOK, "dashboard", gin.H{
+		"Providers": s.buildViews(),
+		"Message":   c.Query("message"),
+		"Error":     c.Query("error"),
+		"CSRFToken": s.csrfToken,
+	})
+}
+
+func (s *Server) refreshResources(c *gin.Context) {
+	s.doRefresh(c.Request.Context())
+	redirect(c, "message", "Resources refreshed")
+}
+
+func (s *Server) resourceAction(c *gin.Context) {
+	action, err := parseAction(c.Param("action"))
+	if err != nil {
+		redirect(c, "error", err.Error())
+		return
+	}
+	providerName, mgrName, key := c.Param("provider"), c.Param("manager"), c.PostForm("resource")
+	if err := s.doAction(c.Request.Context(), providerName, mgrName, key, action); err != nil {
+		redirect(c, "error", err.Error())
+		return
+	}
+	redirect(c, "message", fmt.Sprintf("%s %s/%s requested", action, mgrName, key))
+}
+
+func redirect(c *gin.Context, key, value string) {
+	c.Redirect(http.StatusSeeOther, "/?"+url.Values{key: {value}}.Encode())
+}
+
+func location(r *resource.Resource) string {
+	for _, l := range []string{r.Region, r.Zone, r.Location} {
+		if l != "" {
+			return l
+		}
+	}
+	return "-"
+}
+
+var dashboardTemplate = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Reka</title>
+  <style>
+    body { font-family: sans-serif; margin: 2em; color: #333; }
+    table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+    th, td { text-align: left; padding: .4em .6em; border-bottom: 1px solid #eee; }
+    .badge { padding: .15em .5em; border-radius: .3em; color: #fff; font-size: .85em; }
+    .success { background: #28a745; }
+    .info { background: #17a2b8; }
+    .danger { background: #dc3545; }
+    .alert { padding: .6em; margin-bottom: 1em; }
+    .alert.success, .alert.danger { color: #fff; }
+    form { display: inline; }
+  </style>
+</head>
+<body>
+  <h1>Reka</h1>
+  {{ if .Message }}<div class="alert success">{{ .Message }}</div>{{ end }}
+  {{ if .Error }}<div class="alert danger">{{ .Error }}</div>{{ end }}
+  <form method="post" action="/refresh"><input type="hidden" name="csrf_token" value="{{ .CSRFToken }}"><button type="submit">Refresh resources</button></form>
+  {{- range $p := .Providers }}
+  <h2>{{ $p.Name }}</h2>
+  {{- range $m := $p.Managers }}
+  <h3>{{ $m.Name }} <small>{{ $m.LongName }}</small></h3>
+  {{- if $m.Resources }}
+  <table>
+    <tr><th>Resource</th><th>Account</th><th>Location</th><th>Status</th><th>Desired</th><th>Action</th><th>Rule</th><th></th></tr>
+    {{- range $r := $m.Resources }}
+    <tr>
+      <td>{{ $r.UUID }}</td>
+      <td>{{ $r.AccountID }}</td>
+      <td>{{ $r.Location }}</td>
+      <td><span class="badge {{ $r.Status.StyleClass }}">{{ $r.Status }}</span></td>
+      <td>{{ $r.DesiredStatus }}</td>
+      <td>{{ if $r.Tracked }}{{ $r.Action }}{{ end }}</td>
+      <td>{{ $r.Rule }}</td>
+      <td>
+        {{- if $r.Tracked }}
+        {{- if $r.CanStop }}
+        <form method="post" action="/resources/{{ $p.Name }}/{{ $m.Name }}/stop"><input type="hidden" name="csrf_token" value="{{ $.CSRFToken }}"><input type="hidden" name="resource" value="{{ $r.Key }}"><button type="submit">Stop</button></form>
+        {{- end }}
+        {{- if $r.CanResume }}
+        <form method="post" action="/resources/{{ $p.Name }}/{{ $m.Name }}/resume"><input type="hidden" name="csrf_token" value="{{ $.CSRFToken }}"><input type="hidden" name="resource" value="{{ $r.Key }}"><button type="submit">Resume</button></form>
+        {{- end }}
+        <form method="post" action="/resources/{{ $p.Name }}/{{ $m.Name }}/destroy" onsubmit="return confirm('Destroy {{ $r.Key }}?')"><input type="hidden" name="csrf_token" value="{{ $.CSRFToken }}"><input type="hidden" name="resource" value="{{ $r.Key }}"><button type="submit">Destroy</button></form>
+        {{- end }}
+      </td>
+    </tr>
+    {{- end }}
+  </table>
+  {{- else }}
+  <p>No resources</p>
+  {{- end }}
+  {{- end }}
+  {{- end }}
+</body>
+</html>
+`
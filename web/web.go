@@ -0,0 +1,186 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mensaah/reka/config"
+	"github.com/mensaah/reka/provider/types"
+	"github.com/mensaah/reka/resource"
+	"github.com/mensaah/reka/rules"
+	"github.com/mensaah/reka/state"
+)
+
+// Server serves the reka dashboard
+type Server struct {
+	providers []*types.Provider
+	backend   state.Backender
+	// refresh reloads resources from the providers into the state
+	refresh func(ctx context.Context)
+	// mu ensures only one action or refresh runs at a time
+	mu sync.Mutex
+	// csrfToken is embedded in the dashboard forms so other sites cannot submit them with the browser's credentials
+	csrfToken string
+}
+
+// NewServer returns a Server for providers. refresh is called whenever resources need to be reloaded
+// from the providers
+func NewServer(providers []*types.Provider, backend state.Backender, refresh func(ctx context.Context)) *Server {
+	return &Server{
+		providers: providers,
+		backend:   backend,
+		refresh:   refresh,
+		csrfToken: newCSRFToken(),
+	}
+}
+
+func newCSRFToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Could not generate CSRF token: %s", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Router returns the http handler for the dashboard and API. All dashboard and API routes require the basic auth
+// credentials set in `web.auth`
+func (s *Server) Router() *gin.Engine {
+	cfg := config.GetConfig()
+	if !cfg.Verbose {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	router.Use(gin.Logger(), gin.Recovery())
+	router.SetHTMLTemplate(template.Must(template.New("dashboard").Parse(dashboardTemplate)))
+	if _, err := os.Stat(config.StaticPath()); err == nil {
+		router.Static("/static", config.StaticPath())
+	}
+
+	basicAuth := gin.BasicAuth(gin.Accounts{
+		cfg.Web.Auth.Username: cfg.Web.Auth.Password,
+	})
+	authorized := router.Group("/", basicAuth)
+	authorized.GET("/", s.dashboard)
+	authorized.POST("/refresh", s.checkCSRF, s.refreshResources)
+	authorized.POST("/resources/:provider/:manager/:action", s.checkCSRF, s.resourceAction)
+
+	s.registerAPI(router, basicAuth)
+
+	return router
+}
+
+// checkCSRF rejects dashboard form submissions without the token of the server
+func (s *Server) checkCSRF(c *gin.Context) {
+	token := c.PostForm("csrf_token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.csrfToken)) != 1 {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	c.Next()
+}
+
+// Run starts the server on the address set in `web.address`
+func (s *Server) Run() error {
+	cfg := config.GetConfig()
+	if cfg.Web.Auth.Username == "" || cfg.Web.Auth.Password == "" {
+		return fmt.Errorf("web.auth.username and web.auth.password must be set to run the dashboard")
+	}
+	log.Infof("Reka dashboard listening on %s", cfg.Web.Address)
+	return s.Router().Run(cfg.Web.Address)
+}
+
+func (s *Server) getProvider(name string) *types.Provider {
+	for _, p := range s.providers {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// findResource returns the resource with key in the current state. Keys without the account and region of the
+// resource must only match a single resource
+func (s *Server) findResource(providerName, mgrName, key string) (*resource.Resource, error) {
+	probe := resource.ParseKey(key)
+	var found []*resource.Resource
+	for _, r := range s.backend.GetState().Current[providerName][mgrName] {
+		if r.Is(probe) {
+			found = append(found, r)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("%s resource %s not found in state", mgrName, key)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("%s resource %s matches %d resources, include its account and region", mgrName, key, len(found))
+	}
+}
+
+// doAction performs action on a single resource tracked in state. Excluded resources are refused. Calls to the
+// provider stop when ctx is done
+func (s *Server) doAction(ctx context.Context, providerName, mgrName, key string, action rules.Action) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.getProvider(providerName)
+	if p == nil {
+		return fmt.Errorf("Provider %s is not enabled", providerName)
+	}
+	mgr, ok := p.Managers[mgrName]
+	if !ok {
+		return fmt.Errorf("Provider %s has no resource %s", providerName, mgrName)
+	}
+	res, err := s.findResource(providerName, mgrName, key)
+	if err != nil {
+		return err
+	}
+	if rules.IsExcluded(res) {
+		return fmt.Errorf("%s resource %s is excluded from reka", mgrName, key)
+	}
+
+	resources := types.Resources{mgrName: {res}}
+	var results []*resource.Result
+	switch action {
+	case rules.Stop, rules.Resume:
+		if !mgr.IsStoppable() {
+			return fmt.Errorf("%s resources cannot be stopped or resumed", mgrName)
+		}
+		if action == rules.Stop {
+			results = p.StopResources(ctx, resources)
+		} else {
+			results = p.ResumeResources(ctx, resources)
+		}
+	case rules.Destroy:
+		results = p.DestroyResources(ctx, resources)
+	default:
+		return fmt.Errorf("Invalid action %s", action)
+	}
+	for _, r := range results {
+		switch r.Outcome {
+		case resource.Failed:
+			return fmt.Errorf("%s", r.Reason)
+		case resource.Skipped:
+			return fmt.Errorf("Skipped: %s", r.Reason)
+		}
+	}
+	return nil
+}
+
+func (s *Server) doRefresh(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(ctx)
+}